@@ -0,0 +1,176 @@
+package libuecc
+
+import "sync"
+
+// baseTable holds precomputed multiples of a fixed base point B for
+// fixed-base scalar multiplication.
+//
+// Row i holds the points j*256^i*B for j = 1..8 in affine cached form,
+// so a scalar can be recoded in signed radix 16 and multiplied using
+// only table lookups and mixed additions (plus four doublings).
+type baseTable [33][8]affineCached
+
+// newBaseTable computes the precomputed table for the base point b
+func newBaseTable(b *Point) *baseTable {
+	t := &baseTable{}
+	row := *b
+	for i := range t {
+		cur := row
+		for j := 0; j < 8; j++ {
+			if j > 0 {
+				cur.SetAdd(&cur, &row)
+			}
+			t[i][j] = cur.affineCached()
+		}
+		for j := 0; j < 8; j++ {
+			row.SetDouble(&row)
+		}
+	}
+	return t
+}
+
+// lazyBaseTable builds a baseTable on first use
+type lazyBaseTable struct {
+	once  sync.Once
+	base  *Point
+	table *baseTable
+}
+
+func (l *lazyBaseTable) get() *baseTable {
+	l.once.Do(func() {
+		l.table = newBaseTable(l.base)
+	})
+	return l.table
+}
+
+var (
+	baseTableEd25519 = lazyBaseTable{base: &pointBaseEd25519}
+	baseTableLegacy  = lazyBaseTable{base: &pointBaseLegacy}
+)
+
+// Returns 1 when a == b, 0 otherwise
+//
+// Both values must be smaller than 2^31.
+func equalMask(a, b uint32) uint32 {
+	return ((a ^ b) - 1) >> 31
+}
+
+// Recodes the lowest bits of n into signed radix 16 digits
+//
+// The result satisfies n = sum(e[i] * 16^i) for the lowest 4*(len(e)-1)
+// bits of n, with every digit but the last in [-8, 7]. The last digit is
+// the final carry and either 0 or 1.
+func recodeRadix16(n *Int256, e []int8) {
+	digits := len(e) - 1
+	for i := 0; i < digits; i++ {
+		b := n[i/2]
+		if i&1 == 0 {
+			e[i] = int8(b & 15)
+		} else {
+			e[i] = int8(b >> 4)
+		}
+	}
+
+	carry := int8(0)
+	for i := 0; i < digits; i++ {
+		e[i] += carry
+		carry = (e[i] + 8) >> 4
+		e[i] -= carry << 4
+	}
+	e[digits] = carry
+}
+
+// Selects d*row[0] from a row of the multiples row[0], ..., 8*row[0]
+// for a digit d in [-8, 8]
+//
+// Every entry of the row is touched, so the time needed doesn't depend
+// on d.
+func lookupSigned(row *[8]CachedPoint, d int8) CachedPoint {
+	neg, abs := signedDigit(d)
+
+	out := cachedIdentity
+	for j := range row {
+		out = selectCached(&out, &row[j], equalMask(abs, uint32(j+1)))
+	}
+
+	negated := out.negate()
+	return selectCached(&out, &negated, neg)
+}
+
+// Like lookupSigned, but for a row of affine cached points
+func lookupAffine(row *[8]affineCached, d int8) affineCached {
+	neg, abs := signedDigit(d)
+
+	out := affineIdentity
+	for j := range row {
+		out = selectAffine(&out, &row[j], equalMask(abs, uint32(j+1)))
+	}
+
+	negated := out.negate()
+	return selectAffine(&out, &negated, neg)
+}
+
+// Splits a digit into its sign (1 if negative) and absolute value
+// without branches
+func signedDigit(d int8) (neg, abs uint32) {
+	neg = uint32(uint8(d) >> 7)
+	mask := int8(-int8(neg))
+	abs = uint32(uint8((d ^ mask) - mask))
+	return
+}
+
+// scalarMult sets out to the base point of the table multiplied with n
+func (t *baseTable) scalarMult(out *Point, n *Int256) *Point {
+	var e [65]int8
+	recodeRadix16(n, e[:])
+
+	cur := pointIdentity
+	for i := 1; i < 64; i += 2 {
+		q := lookupAffine(&t[i/2], e[i])
+		cur.setAddAffine(&cur, &q)
+	}
+
+	cur.SetDouble(&cur).SetDouble(&cur).SetDouble(&cur).SetDouble(&cur)
+
+	for i := 0; i < 65; i += 2 {
+		q := lookupAffine(&t[i/2], e[i])
+		cur.setAddAffine(&cur, &q)
+	}
+
+	*out = cur
+	return out
+}
+
+// ScalarMultBaseEd25519 multiplies the Ed25519 generator point with an
+// integer
+//
+// This gives the same result as PointBaseEd25519().ScalarMult(n), but
+// is several times faster as it uses a table of precomputed multiples of
+// the generator point. The table is computed on first use. The time
+// needed doesn't depend on n.
+func ScalarMultBaseEd25519(n *Int256) *Point {
+	return baseTableEd25519.get().scalarMult(new(Point), n)
+}
+
+// SetScalarMultBaseEd25519 sets p to the Ed25519 generator point
+// multiplied with n and returns p
+func (p *Point) SetScalarMultBaseEd25519(n *Int256) *Point {
+	return baseTableEd25519.get().scalarMult(p, n)
+}
+
+// ScalarMultBaseLegacy multiplies the legacy generator point with an
+// integer
+//
+// This gives the same result as PointBaseLegacy().ScalarMult(n), but is
+// several times faster as it uses a table of precomputed multiples of
+// the generator point. The table is computed on first use. The time
+// needed doesn't depend on n.
+func ScalarMultBaseLegacy(n *Int256) *Point {
+	return baseTableLegacy.get().scalarMult(new(Point), n)
+}
+
+// SetScalarMultBaseLegacy sets p to the legacy generator point
+// multiplied with n and returns p
+func (p *Point) SetScalarMultBaseLegacy(n *Int256) *Point {
+	return baseTableLegacy.get().scalarMult(p, n)
+}
@@ -0,0 +1,113 @@
+package libuecc
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// testScalars returns a set of scalars covering edge cases as well as
+// some (deterministic) random values
+func testScalars() []*Int256 {
+	scalars := []*Int256{
+		{},
+		{1},
+		{8},
+		&gfOrder,
+	}
+
+	max := &Int256{}
+	for i := range max {
+		max[i] = 0xff
+	}
+	scalars = append(scalars, max)
+
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 16; i++ {
+		n := &Int256{}
+		rnd.Read(n[:])
+		scalars = append(scalars, n)
+	}
+	return scalars
+}
+
+func TestRecodeRadix16(t *testing.T) {
+	for i, n := range testScalars() {
+		var e [65]int8
+		recodeRadix16(n, e[:])
+
+		// undo the recoding, starting with the most significant digit
+		var sum [33]int32
+		for j := 64; j >= 0; j-- {
+			if j < 64 && (e[j] < -8 || e[j] > 7) {
+				t.Fatalf("scalar %d: digit %d out of range: %d", i, j, e[j])
+			}
+			sum[j/2] += int32(e[j]) << (4 * uint(j&1))
+		}
+		var carry int32
+		var actual Int256
+		for j := 0; j < 32; j++ {
+			carry += sum[j]
+			actual[j] = uint8(carry)
+			carry >>= 8
+		}
+		carry += sum[32]
+
+		if carry != 0 || actual != *n {
+			t.Errorf("scalar %d: recoding does not match: %x", i, n[:])
+		}
+	}
+}
+
+func TestScalarMultBase(t *testing.T) {
+	for i, n := range testScalars() {
+		t.Run(fmt.Sprintf("ed25519_%d", i), func(t *testing.T) {
+			expected := PointBaseEd25519().ScalarMult(n).StorePackedEd25519()
+			actual := ScalarMultBaseEd25519(n).StorePackedEd25519()
+
+			if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+				t.Errorf(errmsg, expected, actual)
+			}
+		})
+
+		t.Run(fmt.Sprintf("legacy_%d", i), func(t *testing.T) {
+			expected := PointBaseLegacy().ScalarMult(n).StorePackedLegacy()
+			actual := ScalarMultBaseLegacy(n).StorePackedLegacy()
+
+			if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+				t.Errorf(errmsg, expected, actual)
+			}
+		})
+	}
+}
+
+func TestScalarMultBaseGeneratedData(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		k := loadInt256File(fmt.Sprintf("testdata/cases/ecc_key_%d", i))
+		expected := loadInt256File(fmt.Sprintf("testdata/cases/ecc_key_derived_public_%d", i))
+
+		actual := ScalarMultBaseLegacy(k).StorePackedLegacy()
+		if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+			t.Errorf(errmsg, expected, actual)
+		}
+	}
+}
+
+func BenchmarkScalarMultBase(b *testing.B) {
+	n := testScalars()[5]
+
+	b.Run("ScalarMult", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			PointBaseEd25519().ScalarMult(n)
+		}
+	})
+
+	b.Run("ScalarMultBaseEd25519", func(b *testing.B) {
+		ScalarMultBaseEd25519(n) // build the table outside of the timing
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ScalarMultBaseEd25519(n)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package libuecc
+
+// CachedPoint is a point of the Elliptic Curve in a form which is
+// precomputed for repeated additions.
+//
+// It stores (Y+X, Y-X, 2d*T, 2Z) of the extended coordinates, so adding
+// a CachedPoint to a Point saves the conversion of the second operand
+// on every addition. Like Point, the representation isn't unique.
+type CachedPoint struct {
+	yPlusX, yMinusX, t2d, z2 fe
+}
+
+// affineCached is a CachedPoint with Z = 1, which saves another
+// multiplication per addition. It is used for the precomputed tables of
+// the generator points.
+type affineCached struct {
+	yPlusX, yMinusX, t2d fe
+}
+
+// 2*d = -121665/60833 (modulo p), where d is the parameter of the curve
+var d2 = feFromUnpacked(unpacked{
+	0x59, 0xf1, 0xb2, 0x26, 0x94, 0x9b, 0xd6, 0xeb,
+	0x56, 0xb1, 0x83, 0x82, 0x9a, 0x14, 0xe0, 0x00,
+	0x30, 0xd1, 0xf3, 0xee, 0xf2, 0x80, 0x8e, 0x19,
+	0xe7, 0xfc, 0xdf, 0x56, 0xdc, 0xd9, 0x06, 0x24,
+})
+
+// The identity element in cached form
+var cachedIdentity = CachedPoint{
+	yPlusX:  feOne,
+	yMinusX: feOne,
+	z2:      feOne.add(feOne),
+}
+
+// The identity element in affine cached form
+var affineIdentity = affineCached{
+	yPlusX:  feOne,
+	yMinusX: feOne,
+}
+
+// Cached returns the cached form of a point
+func (w *Point) Cached() *CachedPoint {
+	return new(CachedPoint).SetPoint(w)
+}
+
+// SetPoint sets c to the cached form of p and returns c
+func (c *CachedPoint) SetPoint(p *Point) *CachedPoint {
+	*c = CachedPoint{
+		yPlusX:  p.Y.add(p.X),
+		yMinusX: p.Y.sub(p.X).squeeze(),
+		t2d:     p.T.mult(d2),
+		z2:      p.Z.add(p.Z),
+	}
+	return c
+}
+
+// Returns the affine cached form of a point, this needs an inversion
+func (w *Point) affineCached() (out affineCached) {
+	Z := w.Z.recip()
+	x := w.X.mult(Z)
+	y := w.Y.mult(Z)
+
+	return affineCached{
+		yPlusX:  y.add(x),
+		yMinusX: y.sub(x).squeeze(),
+		t2d:     x.mult(y).mult(d2),
+	}
+}
+
+// Returns the negation of a cached point
+func (c *CachedPoint) negate() CachedPoint {
+	// No squeeze is necessary after subtractions from zero if the
+	// subtrahend is squeezed
+	return CachedPoint{
+		yPlusX:  c.yMinusX,
+		yMinusX: c.yPlusX,
+		t2d:     feZero.sub(c.t2d),
+		z2:      c.z2,
+	}
+}
+
+// Returns the negation of an affine cached point
+func (c *affineCached) negate() affineCached {
+	return affineCached{
+		yPlusX:  c.yMinusX,
+		yMinusX: c.yPlusX,
+		t2d:     feZero.sub(c.t2d),
+	}
+}
+
+// Copies r to out when b == 0, s when b == 1
+func selectCached(r, s *CachedPoint, b uint32) CachedPoint {
+	return CachedPoint{
+		yPlusX:  selectFe(r.yPlusX, s.yPlusX, b),
+		yMinusX: selectFe(r.yMinusX, s.yMinusX, b),
+		t2d:     selectFe(r.t2d, s.t2d, b),
+		z2:      selectFe(r.z2, s.z2, b),
+	}
+}
+
+// Copies r to out when b == 0, s when b == 1
+func selectAffine(r, s *affineCached, b uint32) affineCached {
+	return affineCached{
+		yPlusX:  selectFe(r.yPlusX, s.yPlusX, b),
+		yMinusX: selectFe(r.yMinusX, s.yMinusX, b),
+		t2d:     selectFe(r.t2d, s.t2d, b),
+	}
+}
+
+// AddCached adds a point in cached form to a point of the Elliptic Curve
+func (w *Point) AddCached(c *CachedPoint) *Point {
+	return new(Point).SetAddCached(w, c)
+}
+
+// SetAddCached sets p to the sum a+c and returns p
+func (p *Point) SetAddCached(a *Point, c *CachedPoint) *Point {
+	return p.addCached(a, c.yPlusX, c.yMinusX, c.t2d, a.Z.mult(c.z2))
+}
+
+// SubCached subtracts a point in cached form from a point of the
+// Elliptic Curve
+func (w *Point) SubCached(c *CachedPoint) *Point {
+	return new(Point).SetSubCached(w, c)
+}
+
+// SetSubCached sets p to the difference a-c and returns p
+func (p *Point) SetSubCached(a *Point, c *CachedPoint) *Point {
+	n := c.negate()
+	return p.SetAddCached(a, &n)
+}
+
+// Sets p to the sum a+c of a point and an affine cached point
+func (p *Point) setAddAffine(a *Point, c *affineCached) *Point {
+	return p.addCached(a, c.yPlusX, c.yMinusX, c.t2d, a.Z.add(a.Z))
+}
+
+// Adds the (affine) cached point (yPlusX, yMinusX, t2d) to a, where D is
+// the product of both Z coordinates times two
+func (p *Point) addCached(a *Point, yPlusX, yMinusX, t2d, D fe) *Point {
+	t0 := a.Y.sub(a.X).squeeze()
+	A := t0.mult(yMinusX)
+
+	t0 = a.Y.add(a.X)
+	B := t0.mult(yPlusX)
+
+	C := a.T.mult(t2d)
+
+	E := B.sub(A)
+	F := D.sub(C)
+	G := D.add(C)
+	H := B.add(A)
+
+	*p = Point{
+		X: E.mult(F),
+		Y: G.mult(H),
+		T: E.mult(H),
+		Z: F.mult(G),
+	}
+	return p
+}
@@ -0,0 +1,39 @@
+package libuecc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedPoint(t *testing.T) {
+	a := PointBaseLegacy().ScalarMult(loadInt256File("testdata/cases/ecc_key_0"))
+
+	for i, n := range testScalars() {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert := assert.New(t)
+			b := PointBaseEd25519().ScalarMult(n)
+			c := b.Cached()
+
+			assert.Equal(a.Add(b).StorePackedEd25519(), a.AddCached(c).StorePackedEd25519())
+			assert.Equal(a.Sub(b).StorePackedEd25519(), a.SubCached(c).StorePackedEd25519())
+			assert.Equal(b.Double().StorePackedEd25519(), b.AddCached(c).StorePackedEd25519())
+			assert.True(b.SubCached(c).IsIdentity())
+
+			ac := b.affineCached()
+			assert.Equal(
+				a.Add(b).StorePackedEd25519(),
+				new(Point).setAddAffine(a, &ac).StorePackedEd25519(),
+			)
+
+			p := *a
+			assert.Zero(testing.AllocsPerRun(10, func() { p.SetAddCached(&p, c) }))
+		})
+	}
+
+	// the cached identity element
+	assert.Equal(t, a.StorePackedEd25519(), a.AddCached(&cachedIdentity).StorePackedEd25519())
+	assert.Equal(t, a.StorePackedEd25519(), a.AddCached(pointIdentity.Cached()).StorePackedEd25519())
+	assert.Equal(t, a.StorePackedEd25519(), new(Point).setAddAffine(a, &affineIdentity).StorePackedEd25519())
+}
@@ -0,0 +1,131 @@
+package libuecc
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+var (
+	// ErrNotOnCurve is returned when decoding coordinates which don't
+	// describe a point of the curve
+	ErrNotOnCurve = errors.New("libuecc: point is not on the curve")
+
+	// ErrNonCanonical is returned when decoding an encoding which isn't
+	// the unique (fully reduced) representation of a point
+	ErrNonCanonical = errors.New("libuecc: non-canonical point encoding")
+
+	// ErrSmallOrder is returned when decoding a point of small order,
+	// i.e. a point P with 8*P = 0, which must not be used as public key
+	ErrSmallOrder = errors.New("libuecc: point of small order")
+)
+
+// Checks if the lower 255 bits of in are a fully reduced integer
+// modulo p
+func isCanonicalCoordinate(in *Int256) bool {
+	v := *in
+	v[31] &= 0x7f
+	return subtle.ConstantTimeCompare(v[:], feFromInt256(&v).toInt256()[:]) == 1
+}
+
+// Checks if in is a fully reduced integer modulo p
+func isCanonicalFe(in *Int256) bool {
+	return in[31]>>7 == 0 && isCanonicalCoordinate(in)
+}
+
+// DecodePackedEd25519 decodes a packed point of the Ed25519 curve
+//
+// In contrast to LoadPackedEd25519, all non-canonical encodings are
+// rejected, and the error tells why the input was rejected:
+// ErrNonCanonical if the Y coordinate isn't in the range [0,p-1] or if
+// the sign bit is set for X = 0, ErrNotOnCurve if there is no point
+// with that Y coordinate and ErrSmallOrder for the points of small
+// order.
+func DecodePackedEd25519(in *Int256) (*Point, error) {
+	return checkOrder(loadPackedEd25519Strict(in))
+}
+
+// DecodePackedLegacy decodes a packed point of the legacy curve
+//
+// In contrast to LoadPackedLegacy, all non-canonical encodings are
+// rejected, and the error tells why the input was rejected:
+// ErrNonCanonical if the X coordinate isn't in the range [0,p-1] or if
+// the sign bit is set for Y = 0, ErrNotOnCurve if there is no point
+// with that X coordinate and ErrSmallOrder for the points of small
+// order.
+func DecodePackedLegacy(in *Int256) (*Point, error) {
+	return checkOrder(loadPackedLegacyStrict(in))
+}
+
+// Loads a packed point of the Ed25519 curve, rejecting all non-canonical
+// encodings
+//
+// Every point has exactly one encoding accepted by this function, as
+// required by RFC 8032.
+func loadPackedEd25519Strict(in *Int256) (*Point, error) {
+	if !isCanonicalCoordinate(in) {
+		return nil, ErrNonCanonical
+	}
+
+	p, err := loadPackedEd25519(in)
+	if err != nil {
+		return nil, err
+	}
+
+	// The sign of X = 0 ("negative zero") must not be set
+	if in[31]>>7 == 1 && p.X.isZero() {
+		return nil, ErrNonCanonical
+	}
+	return p, nil
+}
+
+// Loads a packed point of the legacy curve, rejecting all non-canonical
+// encodings
+func loadPackedLegacyStrict(in *Int256) (*Point, error) {
+	if !isCanonicalCoordinate(in) {
+		return nil, ErrNonCanonical
+	}
+
+	p, err := loadPackedLegacy(in)
+	if err != nil {
+		return nil, err
+	}
+
+	// The sign of Y = 0 ("negative zero") must not be set
+	if in[31]>>7 == 1 && p.Y.isZero() {
+		return nil, ErrNonCanonical
+	}
+	return p, nil
+}
+
+// Passes on the result of a decoder, rejecting points of small order
+func checkOrder(p *Point, err error) (*Point, error) {
+	if err != nil {
+		return nil, err
+	}
+	if p.IsSmallOrder() {
+		return nil, ErrSmallOrder
+	}
+	return p, nil
+}
+
+// DecodeXYEd25519 decodes a point of the Ed25519 curve from its affine
+// coordinates
+//
+// In contrast to LoadXYEd25519, the error tells why the input was
+// rejected: ErrNonCanonical if a coordinate isn't in the range [0,p-1],
+// ErrNotOnCurve if the coordinates don't describe a point of the curve
+// and ErrSmallOrder for the points of small order.
+func DecodeXYEd25519(x, y *Int256) (*Point, error) {
+	return checkOrder(loadXYEd25519(x, y))
+}
+
+// DecodeXYLegacy decodes a point of the legacy curve from its affine
+// coordinates
+//
+// In contrast to LoadXYLegacy, the error tells why the input was
+// rejected: ErrNonCanonical if a coordinate isn't in the range [0,p-1],
+// ErrNotOnCurve if the coordinates don't describe a point of the curve
+// and ErrSmallOrder for the points of small order.
+func DecodeXYLegacy(x, y *Int256) (*Point, error) {
+	return checkOrder(loadXYLegacy(x, y))
+}
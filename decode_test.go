@@ -0,0 +1,77 @@
+package libuecc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePacked(t *testing.T) {
+	for i, n := range testScalars() {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert := assert.New(t)
+			w := PointBaseLegacy().ScalarMult(n)
+			if w.IsSmallOrder() {
+				t.Skip("small order")
+			}
+
+			p, err := DecodePackedEd25519(w.StorePackedEd25519())
+			require.NoError(t, err)
+			assert.Equal(w.StorePackedEd25519(), p.StorePackedEd25519())
+
+			p, err = DecodePackedLegacy(w.StorePackedLegacy())
+			require.NoError(t, err)
+			assert.Equal(w.StorePackedLegacy(), p.StorePackedLegacy())
+		})
+	}
+}
+
+func TestDecodePackedErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	for i, p := range SmallOrderPoints() {
+		_, err := DecodePackedEd25519(p.StorePackedEd25519())
+		assert.Equal(ErrSmallOrder, err, i)
+
+		_, err = DecodePackedLegacy(p.StorePackedLegacy())
+		assert.Equal(ErrSmallOrder, err, i)
+	}
+
+	notOnCurve := &Int256{2}
+	_, err := DecodePackedEd25519(notOnCurve)
+	assert.Equal(ErrNotOnCurve, err)
+	_, err = DecodePackedLegacy(notOnCurve)
+	assert.Equal(ErrNotOnCurve, err)
+
+	for _, s := range []string{
+		"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f", // p
+		"eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", // p+1, with sign bit
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f", // 2^255-1
+	} {
+		in := loadInt256Hex(s)
+		_, err = DecodePackedEd25519(in)
+		assert.Equal(ErrNonCanonical, err, s)
+		_, err = DecodePackedLegacy(in)
+		assert.Equal(ErrNonCanonical, err, s)
+	}
+}
+
+func TestDecodePackedNegativeZero(t *testing.T) {
+	assert := assert.New(t)
+
+	// identity with the sign bit of X = 0 set
+	in := loadInt256Hex("0100000000000000000000000000000000000000000000000000000000000080")
+	assert.NotNil(in.LoadPackedEd25519())
+	_, err := DecodePackedEd25519(in)
+	assert.Equal(ErrNonCanonical, err)
+
+	// point of order 4 (Y = 0) with the sign bit of Y set
+	in = loadInt256Hex("0000000000000000000000000000000000000000000000000000000000000000").
+		LoadPackedEd25519().StorePackedLegacy()
+	in[31] |= 0x80
+	assert.NotNil(in.LoadPackedLegacy())
+	_, err = DecodePackedLegacy(in)
+	assert.Equal(ErrNonCanonical, err)
+}
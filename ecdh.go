@@ -0,0 +1,157 @@
+package libuecc
+
+import (
+	"errors"
+	"io"
+)
+
+var errKeySize = errors.New("libuecc: invalid key length")
+
+// PrivateKey is a secret key for Diffie-Hellman key exchanges on the
+// Elliptic Curve, as used by fastd
+//
+// The secret scalar is the same for the Ed25519 and the legacy
+// representation, only the encoding of the public keys differs. The zero
+// value isn't a usable key, as its secret is zero.
+type PrivateKey struct {
+	secret Int256 // always sanitized
+}
+
+// PublicKey is a public key for Diffie-Hellman key exchanges on the
+// Elliptic Curve
+//
+// Public keys loaded by PublicKeyFromBytesLegacy and
+// PublicKeyFromBytesEd25519 or derived from keys of GenerateKey and
+// PrivateKeyFromBytes are never of small order. The only exception is
+// the public key of the zero value of PrivateKey, which is the identity
+// element.
+type PublicKey struct {
+	point Point
+}
+
+// GenerateKey generates a new private key, reading 32 bytes from rand
+// (usually crypto/rand.Reader)
+func GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	var b [32]byte
+	if _, err := io.ReadFull(rand, b[:]); err != nil {
+		return nil, err
+	}
+	return PrivateKeyFromBytes(b[:])
+}
+
+// PrivateKeyFromBytes loads a 32 byte private key
+//
+// Like all secret keys, the key is sanitized (see SanitizeSecret), so
+// Bytes may return a different value.
+func PrivateKeyFromBytes(b []byte) (*PrivateKey, error) {
+	if len(b) != 32 {
+		return nil, errKeySize
+	}
+	return &PrivateKey{secret: *NewInt256(b).SanitizeSecret()}, nil
+}
+
+// Bytes returns the 32 byte encoding of the sanitized private key
+func (k *PrivateKey) Bytes() []byte {
+	out := make([]byte, 32)
+	copy(out, k.secret[:])
+	return out
+}
+
+// PublicKey derives the public key of a private key
+//
+// The public key of the zero value of PrivateKey is the identity
+// element, which PublicKeyFromBytesLegacy and PublicKeyFromBytesEd25519
+// reject.
+func (k *PrivateKey) PublicKey() *PublicKey {
+	return &PublicKey{point: *ScalarMultBaseEd25519(&k.secret)}
+}
+
+// ECDH computes the shared secret of a Diffie-Hellman key exchange with
+// the public key of a peer, as packed point in the legacy representation
+// used by fastd
+//
+// ErrSmallOrder is returned if the public key of the peer is of small
+// order (including the identity element), as the shared secret wouldn't
+// depend on the private key then.
+func (k *PrivateKey) ECDH(peer *PublicKey) ([]byte, error) {
+	p, err := k.sharedPoint(peer)
+	if err != nil {
+		return nil, err
+	}
+	return p.StorePackedLegacy().Bytes(), nil
+}
+
+// ECDHEd25519 computes the shared secret of a Diffie-Hellman key exchange
+// like ECDH, but stores it in the Ed25519 representation
+func (k *PrivateKey) ECDHEd25519(peer *PublicKey) ([]byte, error) {
+	p, err := k.sharedPoint(peer)
+	if err != nil {
+		return nil, err
+	}
+	return p.StorePackedEd25519().Bytes(), nil
+}
+
+// Multiplies the public key of a peer with the private key, rejecting
+// public keys of small order
+func (k *PrivateKey) sharedPoint(peer *PublicKey) (*Point, error) {
+	if peer.point.IsSmallOrder() {
+		return nil, ErrSmallOrder
+	}
+
+	// No sanitized key is a multiple of the order of the base point, so
+	// the result can only be the identity for the zero value of
+	// PrivateKey, whose secret isn't sanitized
+	p := peer.point.ScalarMult(&k.secret)
+	if p.IsIdentity() {
+		return nil, ErrSmallOrder
+	}
+	return p, nil
+}
+
+// PublicKeyFromBytesLegacy loads a public key from a packed point in the
+// legacy representation
+//
+// The encoding is decoded by DecodePackedLegacy, so non-canonical
+// encodings and points of small order are rejected.
+func PublicKeyFromBytesLegacy(b []byte) (*PublicKey, error) {
+	if len(b) != 32 {
+		return nil, errKeySize
+	}
+	p, err := DecodePackedLegacy(NewInt256(b))
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{point: *p}, nil
+}
+
+// PublicKeyFromBytesEd25519 loads a public key from a packed point in the
+// Ed25519 representation
+//
+// The encoding is decoded by DecodePackedEd25519, so non-canonical
+// encodings and points of small order are rejected.
+func PublicKeyFromBytesEd25519(b []byte) (*PublicKey, error) {
+	if len(b) != 32 {
+		return nil, errKeySize
+	}
+	p, err := DecodePackedEd25519(NewInt256(b))
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{point: *p}, nil
+}
+
+// BytesLegacy returns the packed legacy representation of a public key
+func (pub *PublicKey) BytesLegacy() []byte {
+	return pub.point.StorePackedLegacy().Bytes()
+}
+
+// BytesEd25519 returns the packed Ed25519 representation of a public key
+func (pub *PublicKey) BytesEd25519() []byte {
+	return pub.point.StorePackedEd25519().Bytes()
+}
+
+// Point returns a copy of the point of a public key
+func (pub *PublicKey) Point() *Point {
+	p := pub.point
+	return &p
+}
@@ -0,0 +1,131 @@
+package libuecc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECDH(t *testing.T) {
+	assert := assert.New(t)
+
+	alice, err := GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	bob, err := GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	// the public keys survive a round trip through both representations
+	pub, err := PublicKeyFromBytesLegacy(alice.PublicKey().BytesLegacy())
+	require.NoError(t, err)
+	assert.True(pub.Point().Equal(alice.PublicKey().Point()))
+
+	pub, err = PublicKeyFromBytesEd25519(alice.PublicKey().BytesEd25519())
+	require.NoError(t, err)
+	assert.True(pub.Point().Equal(alice.PublicKey().Point()))
+
+	s1, err := alice.ECDH(bob.PublicKey())
+	require.NoError(t, err)
+	s2, err := bob.ECDH(alice.PublicKey())
+	require.NoError(t, err)
+	assert.Equal(s1, s2)
+
+	s1, err = alice.ECDHEd25519(bob.PublicKey())
+	require.NoError(t, err)
+	s2, err = bob.ECDHEd25519(alice.PublicKey())
+	require.NoError(t, err)
+	assert.Equal(s1, s2)
+
+	// the shared secret matches the hand-wired fastd key exchange
+	secret := NewInt256(alice.Bytes())
+	peer := NewInt256(bob.PublicKey().BytesLegacy())
+	expected := peer.LoadPackedLegacy().ScalarMult(secret).StorePackedLegacy()
+	actual, err := alice.ECDH(bob.PublicKey())
+	require.NoError(t, err)
+	assert.Equal(expected.Bytes(), actual)
+}
+
+func TestECDHKnownKeys(t *testing.T) {
+	// the keys of TestKeyLoading
+	for i, s := range []string{
+		"83369beddca777585167520fb54a7fb059102bf4e0a46dd5fb1c633d83db77a2",
+		"b4dbdb0c05dd28204534fa27c5afca4dcda5397d833e3064f7a7281b249dc7c7",
+	} {
+		k, err := PrivateKeyFromBytes(loadInt256Hex(s)[:])
+		require.NoError(t, err)
+		assert.Equal(t, loadInt256Hex(s).SanitizeSecret()[:], k.Bytes(), "%d", i)
+
+		// the private key is usable for X25519 as well
+		u, err := X25519(toArray(k.Bytes()), [32]byte{9})
+		require.NoError(t, err)
+		assert.Equal(t, u, k.PublicKey().Point().MontgomeryU(), "%d", i)
+	}
+}
+
+func TestECDHSmallOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	k, err := GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for i, p := range SmallOrderPoints() {
+		_, err := PublicKeyFromBytesEd25519(p.StorePackedEd25519()[:])
+		assert.Equal(ErrSmallOrder, err, "%d", i)
+
+		_, err = PublicKeyFromBytesLegacy(p.StorePackedLegacy()[:])
+		assert.Equal(ErrSmallOrder, err, "%d", i)
+
+		_, err = k.ECDH(&PublicKey{point: *p})
+		assert.Equal(ErrSmallOrder, err, "%d", i)
+
+		_, err = k.ECDHEd25519(&PublicKey{point: *p})
+		assert.Equal(ErrSmallOrder, err, "%d", i)
+	}
+
+	// the zero values are rejected as well
+	_, err = k.ECDH(&PublicKey{})
+	assert.Equal(ErrSmallOrder, err)
+
+	_, err = new(PrivateKey).ECDH(k.PublicKey())
+	assert.Equal(ErrSmallOrder, err)
+}
+
+func TestPrivateKeyZero(t *testing.T) {
+	assert := assert.New(t)
+
+	// the zero value gives the identity element
+	pub := new(PrivateKey).PublicKey()
+	assert.True(pub.Point().IsIdentity())
+
+	_, err := PublicKeyFromBytesLegacy(pub.BytesLegacy())
+	assert.Equal(ErrSmallOrder, err)
+	_, err = PublicKeyFromBytesEd25519(pub.BytesEd25519())
+	assert.Equal(ErrSmallOrder, err)
+
+	// a zero secret is sanitized
+	k, err := PrivateKeyFromBytes(make([]byte, 32))
+	require.NoError(t, err)
+	assert.False(k.PublicKey().Point().IsSmallOrder())
+}
+
+func TestECDHInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := PrivateKeyFromBytes(make([]byte, 31))
+	assert.Equal(errKeySize, err)
+
+	_, err = PublicKeyFromBytesLegacy(make([]byte, 33))
+	assert.Equal(errKeySize, err)
+
+	_, err = PublicKeyFromBytesEd25519(make([]byte, 31))
+	assert.Equal(errKeySize, err)
+
+	_, err = PublicKeyFromBytesEd25519((&Int256{2})[:])
+	assert.Equal(ErrNotOnCurve, err)
+
+	_, err = GenerateKey(bytes.NewReader(make([]byte, 16)))
+	assert.Equal(io.ErrUnexpectedEOF, err)
+}
@@ -0,0 +1,278 @@
+package libuecc
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+)
+
+// SignatureSize is the size of an Ed25519 signature in bytes
+const SignatureSize = 64
+
+// MaxContextSize is the maximum length of an Ed25519ctx or Ed25519ph
+// context in bytes
+const MaxContextSize = 255
+
+var (
+	errContextSize = errors.New("libuecc: invalid Ed25519 context size")
+	errDigestSize  = errors.New("libuecc: invalid SHA-512 digest size")
+)
+
+// Prefix of the dom2 domain separator
+const dom2Prefix = "SigEd25519 no Ed25519 collisions"
+
+// Builds the domain separator for Ed25519ctx (phflag = 0) and Ed25519ph
+// (phflag = 1), see RFC 8032, section 5.1
+func dom2(phflag byte, context []byte) []byte {
+	dom := make([]byte, 0, len(dom2Prefix)+2+len(context))
+	dom = append(dom, dom2Prefix...)
+	dom = append(dom, phflag, byte(len(context)))
+	return append(dom, context...)
+}
+
+// Hashes the concatenation of the given parts with SHA-512 and reduces
+// the digest modulo the group order
+func hashToScalar(parts ...[]byte) *Int256 {
+	h := sha512.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+
+	var digest [64]byte
+	h.Sum(digest[:0])
+	return GfReduceWide(digest)
+}
+
+// Expands a secret seed into the secret scalar and the prefix used to
+// derive the nonces (see RFC 8032, section 5.1.5)
+func expandSeed(seed *Int256) (s *Int256, prefix []byte) {
+	h := sha512.Sum512(seed[:])
+	return NewInt256(h[:32]).SanitizeSecret(), h[32:]
+}
+
+// PublicKeyEd25519 derives the packed Ed25519 public key from a secret
+// seed
+func PublicKeyEd25519(seed *Int256) *Int256 {
+	s, _ := expandSeed(seed)
+	return ScalarMultBaseEd25519(s).StorePackedEd25519()
+}
+
+// Sign signs a message with a secret seed, using the Ed25519 signature
+// scheme as specified in RFC 8032
+//
+// The returned signature is SignatureSize bytes long.
+func Sign(seed *Int256, msg []byte) []byte {
+	return sign(seed, nil, msg)
+}
+
+// SignCtx signs a message with a secret seed, using the Ed25519ctx
+// signature scheme as specified in RFC 8032
+//
+// The context must not be empty and at most MaxContextSize bytes long.
+func SignCtx(seed *Int256, msg, context []byte) ([]byte, error) {
+	if len(context) == 0 || len(context) > MaxContextSize {
+		return nil, errContextSize
+	}
+	return sign(seed, dom2(0, context), msg), nil
+}
+
+// SignPh signs the SHA-512 digest of a message with a secret seed,
+// using the Ed25519ph signature scheme as specified in RFC 8032
+//
+// The context may be empty and is at most MaxContextSize bytes long.
+func SignPh(seed *Int256, digest, context []byte) ([]byte, error) {
+	if len(digest) != sha512.Size {
+		return nil, errDigestSize
+	}
+	if len(context) > MaxContextSize {
+		return nil, errContextSize
+	}
+	return sign(seed, dom2(1, context), digest), nil
+}
+
+// Signs a message, dom is the domain separator (or nil for plain Ed25519)
+func sign(seed *Int256, dom, msg []byte) []byte {
+	s, prefix := expandSeed(seed)
+	A := ScalarMultBaseEd25519(s).StorePackedEd25519()
+
+	r := hashToScalar(dom, prefix, msg)
+	R := ScalarMultBaseEd25519(r).StorePackedEd25519()
+
+	k := hashToScalar(dom, R[:], A[:], msg)
+	S := r.GfAdd(k.GfMult(s)).GfReduce()
+
+	sig := make([]byte, SignatureSize)
+	copy(sig, R[:])
+	copy(sig[32:], S[:])
+	return sig
+}
+
+// Verify checks an Ed25519 signature of a message against a packed
+// Ed25519 public key, as specified in RFC 8032
+//
+// Public keys, R and S values with a non-canonical encoding are
+// rejected. The cofactored verification equation 8*S*B = 8*R + 8*k*A is
+// checked, which RFC 8032 recommends and which makes the results of
+// Verify and VerifyBatch agree. As the inputs are public, the time
+// needed depends on them.
+func Verify(pub *Int256, msg, sig []byte) bool {
+	return verify(pub, nil, msg, sig)
+}
+
+// VerifyZIP215 checks an Ed25519 signature of a message against a packed
+// Ed25519 public key, using the validation rules of ZIP-215
+//
+// In contrast to Verify, the public key and R may use non-canonical
+// encodings (see LoadPackedEd25519). This makes the result agree with
+// other ZIP-215 implementations, which is needed for consensus critical
+// applications. S must still be canonical.
+func VerifyZIP215(pub *Int256, msg, sig []byte) bool {
+	if len(sig) != SignatureSize {
+		return false
+	}
+
+	A := pub.LoadPackedEd25519()
+	R := NewInt256(sig[:32]).LoadPackedEd25519()
+	if A == nil || R == nil {
+		return false
+	}
+
+	S := NewInt256(sig[32:])
+	if *S.GfReduce() != *S {
+		return false
+	}
+
+	k := hashToScalar(sig[:32], pub[:], msg)
+
+	// 8 * (S*B - k*A - R) = 0
+	return DoubleScalarMultVartime(k, A.Negate(), S).Sub(R).IsSmallOrder()
+}
+
+// VerifyCtx checks an Ed25519ctx signature of a message against a packed
+// Ed25519 public key, as specified in RFC 8032
+func VerifyCtx(pub *Int256, msg, sig, context []byte) bool {
+	if len(context) == 0 || len(context) > MaxContextSize {
+		return false
+	}
+	return verify(pub, dom2(0, context), msg, sig)
+}
+
+// VerifyPh checks an Ed25519ph signature of the SHA-512 digest of a
+// message against a packed Ed25519 public key, as specified in RFC 8032
+func VerifyPh(pub *Int256, digest, sig, context []byte) bool {
+	if len(digest) != sha512.Size || len(context) > MaxContextSize {
+		return false
+	}
+	return verify(pub, dom2(1, context), digest, sig)
+}
+
+// Verifies a signature, dom is the domain separator (or nil for plain
+// Ed25519)
+func verify(pub *Int256, dom, msg, sig []byte) bool {
+	if len(sig) != SignatureSize {
+		return false
+	}
+
+	A, err := loadPackedEd25519Strict(pub)
+	if err != nil {
+		return false
+	}
+
+	S := NewInt256(sig[32:])
+	if *S.GfReduce() != *S {
+		return false
+	}
+
+	R, err := loadPackedEd25519Strict(NewInt256(sig[:32]))
+	if err != nil {
+		return false
+	}
+
+	k := hashToScalar(dom, sig[:32], pub[:], msg)
+
+	// 8 * (S*B - k*A - R) = 0
+	return DoubleScalarMultVartime(k, A.Negate(), S).Sub(R).IsSmallOrder()
+}
+
+// VerifyBatch checks many Ed25519 signatures at once
+//
+// All signatures are checked with a single multi-scalar multiplication
+// of a random linear combination of the verification equations, which
+// is considerably faster than separate calls to Verify. Only if this
+// fails, every signature is verified on its own to find the invalid
+// ones.
+//
+// ok reports whether all signatures are valid, valid holds the result
+// for each signature. Both the combined and the separate checks use the
+// cofactored verification equation, so valid[i] is always the result of
+// Verify for the i-th signature, no matter which signatures it is
+// batched with.
+func VerifyBatch(pubs []*Int256, msgs, sigs [][]byte) (ok bool, valid []bool) {
+	if len(pubs) != len(msgs) || len(pubs) != len(sigs) {
+		return false, nil
+	}
+
+	valid = make([]bool, len(pubs))
+	if verifyBatch(pubs, msgs, sigs) {
+		for i := range valid {
+			valid[i] = true
+		}
+		return true, valid
+	}
+
+	ok = true
+	for i := range pubs {
+		valid[i] = Verify(pubs[i], msgs[i], sigs[i])
+		ok = ok && valid[i]
+	}
+	return ok, valid
+}
+
+// Checks the combined verification equation
+//
+//	8 * (sum(z_i*S_i)*B - sum(z_i*R_i) - sum(z_i*k_i*A_i)) = 0
+//
+// for random 128 bit values z_i.
+func verifyBatch(pubs []*Int256, msgs, sigs [][]byte) bool {
+	n := len(pubs)
+	scalars := make([]*Int256, 1, 2*n+1)
+	points := make([]*Point, 1, 2*n+1)
+
+	sumS := &Int256{}
+	for i := range pubs {
+		if len(sigs[i]) != SignatureSize {
+			return false
+		}
+
+		A, err := loadPackedEd25519Strict(pubs[i])
+		if err != nil {
+			return false
+		}
+		R, err := loadPackedEd25519Strict(NewInt256(sigs[i][:32]))
+		if err != nil {
+			return false
+		}
+
+		S := NewInt256(sigs[i][32:])
+		if *S.GfReduce() != *S {
+			return false
+		}
+
+		z := &Int256{}
+		if _, err := rand.Read(z[:16]); err != nil {
+			return false
+		}
+
+		k := hashToScalar(sigs[i][:32], pubs[i][:], msgs[i])
+
+		sumS = sumS.GfAdd(z.GfMult(S))
+		scalars = append(scalars, z, z.GfMult(k))
+		points = append(points, R.Negate(), A.Negate())
+	}
+
+	scalars[0] = sumS.GfReduce()
+	points[0] = PointBaseEd25519()
+
+	sum := MultiScalarMultVartime(scalars, points)
+	return sum.IsSmallOrder()
+}
@@ -0,0 +1,287 @@
+package libuecc
+
+import (
+	"bufio"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadVectors reads colon separated, hex encoded test vectors
+func loadVectors(t *testing.T, fname string) [][][]byte {
+	f, err := os.Open(fname)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var vectors [][][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		var fields [][]byte
+		for _, field := range strings.Split(line, ":") {
+			b, err := hex.DecodeString(field)
+			require.NoError(t, err)
+			fields = append(fields, b)
+		}
+		vectors = append(vectors, fields)
+	}
+	require.NoError(t, scanner.Err())
+	return vectors
+}
+
+func TestEd25519RFC8032(t *testing.T) {
+	for _, v := range loadVectors(t, "testdata/rfc8032/ed25519.txt") {
+		seed, pub, msg, sig := NewInt256(v[0]), NewInt256(v[1]), v[2], v[3]
+
+		t.Run(hex.EncodeToString(pub[:4]), func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(pub, PublicKeyEd25519(seed))
+			assert.Equal(sig, Sign(seed, msg))
+			assert.True(Verify(pub, msg, sig))
+		})
+	}
+}
+
+func TestEd25519Verify(t *testing.T) {
+	assert := assert.New(t)
+
+	seed := loadInt256File("testdata/cases/ecc_key_0")
+	pub := PublicKeyEd25519(seed)
+	msg := []byte("test message")
+	sig := Sign(seed, msg)
+
+	assert.True(Verify(pub, msg, sig))
+	assert.False(Verify(pub, []byte("other message"), sig))
+	assert.False(Verify(pub, msg, sig[:SignatureSize-1]))
+	assert.False(Verify(PublicKeyEd25519(loadInt256File("testdata/cases/ecc_key_1")), msg, sig))
+
+	for _, pos := range []int{0, 31, 32, 63} {
+		tampered := append([]byte{}, sig...)
+		tampered[pos] ^= 0x01
+		assert.False(Verify(pub, msg, tampered), "tampered byte %d", pos)
+	}
+
+	// S+q is congruent to S, but not canonical
+	malleable := append([]byte{}, sig...)
+	S := NewInt256(sig[32:])
+	var u uint32
+	for i := range S {
+		u += uint32(S[i]) + uint32(gfOrder[i])
+		malleable[32+i] = uint8(u)
+		u >>= 8
+	}
+	assert.False(Verify(pub, msg, malleable))
+}
+
+func TestEd25519ctxRFC8032(t *testing.T) {
+	for _, v := range loadVectors(t, "testdata/rfc8032/ed25519ctx.txt") {
+		seed, pub, msg, context, sig := NewInt256(v[0]), NewInt256(v[1]), v[2], v[3], v[4]
+
+		t.Run(hex.EncodeToString(sig[:4]), func(t *testing.T) {
+			assert := assert.New(t)
+
+			actual, err := SignCtx(seed, msg, context)
+			require.NoError(t, err)
+			assert.Equal(sig, actual)
+
+			assert.True(VerifyCtx(pub, msg, sig, context))
+			assert.False(VerifyCtx(pub, msg, sig, []byte("other")))
+			assert.False(Verify(pub, msg, sig))
+		})
+	}
+}
+
+func TestEd25519phRFC8032(t *testing.T) {
+	for _, v := range loadVectors(t, "testdata/rfc8032/ed25519ph.txt") {
+		seed, pub, msg, context, sig := NewInt256(v[0]), NewInt256(v[1]), v[2], v[3], v[4]
+		digest := sha512.Sum512(msg)
+
+		t.Run(hex.EncodeToString(sig[:4]), func(t *testing.T) {
+			assert := assert.New(t)
+
+			actual, err := SignPh(seed, digest[:], context)
+			require.NoError(t, err)
+			assert.Equal(sig, actual)
+
+			assert.True(VerifyPh(pub, digest[:], sig, context))
+			assert.False(VerifyPh(pub, digest[:], sig, []byte("other")))
+			assert.False(Verify(pub, digest[:], sig))
+			assert.False(Verify(pub, msg, sig))
+		})
+	}
+}
+
+func TestEd25519InvalidOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	seed := loadInt256File("testdata/cases/ecc_key_0")
+	digest := sha512.Sum512([]byte("test message"))
+	long := make([]byte, MaxContextSize+1)
+
+	_, err := SignCtx(seed, digest[:], nil)
+	assert.Error(err)
+	_, err = SignCtx(seed, digest[:], long)
+	assert.Error(err)
+	_, err = SignPh(seed, digest[:32], nil)
+	assert.Error(err)
+	_, err = SignPh(seed, digest[:], long)
+	assert.Error(err)
+
+	_, err = SignCtx(seed, digest[:], long[:MaxContextSize])
+	assert.NoError(err)
+}
+
+func testBatch(n int) (pubs []*Int256, msgs, sigs [][]byte) {
+	for i, seed := range testScalars()[:n] {
+		msg := []byte(fmt.Sprintf("message %d", i))
+		pubs = append(pubs, PublicKeyEd25519(seed))
+		msgs = append(msgs, msg)
+		sigs = append(sigs, Sign(seed, msg))
+	}
+	return
+}
+
+func TestEd25519VerifyBatch(t *testing.T) {
+	assert := assert.New(t)
+	pubs, msgs, sigs := testBatch(8)
+
+	ok, valid := VerifyBatch(pubs, msgs, sigs)
+	assert.True(ok)
+	assert.Equal([]bool{true, true, true, true, true, true, true, true}, valid)
+
+	ok, valid = VerifyBatch(nil, nil, nil)
+	assert.True(ok)
+	assert.Empty(valid)
+
+	ok, valid = VerifyBatch(pubs, msgs[:7], sigs)
+	assert.False(ok)
+	assert.Nil(valid)
+
+	// invalid signature
+	sigs[2] = append([]byte{}, sigs[2]...)
+	sigs[2][40] ^= 0x10
+
+	// swapped messages
+	msgs[5], msgs[6] = msgs[6], msgs[5]
+
+	ok, valid = VerifyBatch(pubs, msgs, sigs)
+	assert.False(ok)
+	assert.Equal([]bool{true, true, false, true, true, false, false, true}, valid)
+
+	// truncated signature
+	sigs[2] = sigs[2][:10]
+	ok, valid = VerifyBatch(pubs, msgs, sigs)
+	assert.False(ok)
+	assert.False(valid[2])
+}
+
+func BenchmarkEd25519Verify(b *testing.B) {
+	pubs, msgs, sigs := testBatch(16)
+
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range pubs {
+				Verify(pubs[j], msgs[j], sigs[j])
+			}
+		}
+	})
+
+	b.Run("VerifyBatch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			VerifyBatch(pubs, msgs, sigs)
+		}
+	})
+}
+
+func TestEd25519NonCanonical(t *testing.T) {
+	const (
+		identity         = "0100000000000000000000000000000000000000000000000000000000000000"
+		identityNonCanon = "eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f" // p+1
+		identityNegZero  = "0100000000000000000000000000000000000000000000000000000000000080"
+		order8           = "26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05"
+	)
+
+	// All cases satisfy the cofactored verification equation with S = 0,
+	// but use non-canonical encodings or a small order public key
+	tt := map[string]struct {
+		pub, R string
+		strict bool
+	}{
+		"canonical":       {identity, identity, true},
+		"pub_non_canon":   {identityNonCanon, identity, false},
+		"pub_neg_zero":    {identityNegZero, identity, false},
+		"R_non_canon":     {identity, identityNonCanon, false},
+		"R_neg_zero":      {identity, identityNegZero, false},
+		"pub_small_order": {order8, identity, false},
+	}
+
+	msg := []byte("ZIP-215")
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			pub := loadInt256Hex(tc.pub)
+			sig := make([]byte, SignatureSize)
+			copy(sig, loadInt256Hex(tc.R)[:])
+
+			// the canonically encoded small order public key satisfies the
+			// cofactored equation used by Verify
+			expected := tc.strict || tc.pub == order8
+
+			assert.Equal(expected, Verify(pub, msg, sig))
+			assert.True(VerifyZIP215(pub, msg, sig))
+
+			// the result doesn't depend on the other signatures of the
+			// batch
+			seed := loadInt256File("testdata/cases/ecc_key_0")
+			pubs := []*Int256{pub, PublicKeyEd25519(seed)}
+			msgs := [][]byte{msg, msg}
+			for _, sigs := range [][][]byte{
+				{sig, Sign(seed, msg)},
+				{sig, Sign(seed, []byte("other message"))},
+			} {
+				ok, valid := VerifyBatch(pubs[:1], msgs[:1], sigs[:1])
+				assert.Equal(expected, ok)
+				assert.Equal([]bool{expected}, valid)
+
+				_, valid = VerifyBatch(pubs, msgs, sigs)
+				assert.Equal(expected, valid[0])
+				assert.Equal(Verify(pubs[1], msgs[1], sigs[1]), valid[1])
+			}
+		})
+	}
+}
+
+func TestEd25519VerifyZIP215(t *testing.T) {
+	assert := assert.New(t)
+
+	seed := loadInt256File("testdata/cases/ecc_key_0")
+	pub := PublicKeyEd25519(seed)
+	msg := []byte("test message")
+	sig := Sign(seed, msg)
+
+	assert.True(VerifyZIP215(pub, msg, sig))
+	assert.False(VerifyZIP215(pub, []byte("other message"), sig))
+	assert.False(VerifyZIP215(pub, msg, sig[:SignatureSize-1]))
+
+	// S+q is congruent to S, but not canonical
+	malleable := append([]byte{}, sig...)
+	var u uint32
+	for i := 0; i < 32; i++ {
+		u += uint32(sig[32+i]) + uint32(gfOrder[i])
+		malleable[32+i] = uint8(u)
+		u >>= 8
+	}
+	assert.False(VerifyZIP215(pub, msg, malleable))
+}
@@ -0,0 +1,213 @@
+package libuecc
+
+// The arithmetic of the prime field modulo p = 2^255 - 19 is implemented
+// by the type fe, which is defined depending on the platform:
+//
+//   - fe_51.go uses five limbs of 51 bits and math/bits.Mul64 on 64-bit
+//     platforms
+//   - fe_generic.go uses the 8-bit unpacked representation of the
+//     original libuecc everywhere else, or when building with the
+//     libuecc_generic tag
+//
+// Both provide the methods add, sub, squeeze, mult, square, multInt,
+// canonical, parity, equals, equalsBit, isZero, isZeroBit and toInt256,
+// the code in this file is shared.
+
+var feZero = feFromUnpacked(zero)
+var feOne = feFromUnpacked(one)
+
+var minus1 = feFromUnpacked(unpacked{
+	0xec, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
+})
+
+var ρS = feFromUnpacked(unpacked{ // rho_s
+	0xb0, 0xa0, 0x0e, 0x4a, 0x27, 0x1b, 0xee, 0xc4,
+	0x78, 0xe4, 0x2f, 0xad, 0x06, 0x18, 0x43, 0x2f,
+	0xa7, 0xd7, 0xfb, 0x3d, 0x99, 0x00, 0x4d, 0x2b,
+	0x0b, 0xdf, 0xc1, 0x4f, 0x80, 0x24, 0x83, 0x2b,
+})
+
+// Computes the square root of an integer (in the prime field modulo p)
+//
+// If the given integer has no square root, hasRoot=false is returned
+func (a fe) sqrt() (out fe, hasRoot bool) {
+	// raise z to power (2^252-2), check if power (2^253-5) equals -1
+	a2 := a.square()      // 2
+	t1 := a2.square()     // 4
+	t0 := t1.square()     // 8
+	a9 := t0.mult(a)      // 9
+	a11 := a9.mult(a2)    // 11
+	t0 = a11.square()     // 22
+	a2_5_0 := t0.mult(a9) // 2^5 - 2^0 = 31
+
+	t0 = a2_5_0.square()       // 2^6 - 2^1
+	t1 = t0.square()           // 2^7 - 2^2
+	t0 = t1.square()           // 2^8 - 2^3
+	t1 = t0.square()           // 2^9 - 2^4
+	t0 = t1.square()           // 2^10 - 2^5
+	a2_10_0 := t0.mult(a2_5_0) // 2^10 - 2^0
+
+	t0 = a2_10_0.square()        // 2^11 - 2^1
+	t1 = t0.square()             // 2^12 - 2^2
+	for i := 2; i < 10; i += 2 { // 2^20 - 2^10
+		t0 = t1.square()
+		t1 = t0.square()
+	}
+	a2_20_0 := t1.mult(a2_10_0) // 2^20 - 2^0
+
+	t0 = a2_20_0.square()        // 2^21 - 2^1
+	t1 = t0.square()             // 2^22 - 2^2
+	for i := 2; i < 20; i += 2 { // 2^40 - 2^20
+		t0 = t1.square()
+		t1 = t0.square()
+	}
+	t0 = t1.mult(a2_20_0) // 2^40 - 2^0
+
+	t1 = t0.square()             // 2^41 - 2^1
+	t0 = t1.square()             // 2^42 - 2^2
+	for i := 2; i < 10; i += 2 { // 2^50 - 2^10
+		t1 = t0.square()
+		t0 = t1.square()
+	}
+	a2_50_0 := t0.mult(a2_10_0) // 2^50 - 2^0
+
+	t0 = a2_50_0.square()        // 2^51 - 2^1
+	t1 = t0.square()             // 2^52 - 2^2
+	for i := 2; i < 50; i += 2 { // 2^100 - 2^50
+		t0 = t1.square()
+		t1 = t0.square()
+	}
+	a2_100_0 := t1.mult(a2_50_0) // 2^100 - 2^0
+
+	t1 = a2_100_0.square()        // 2^101 - 2^1
+	t0 = t1.square()              // 2^102 - 2^2
+	for i := 2; i < 100; i += 2 { // 2^200 - 2^100
+		t1 = t0.square()
+		t0 = t1.square()
+	}
+	t1 = t0.mult(a2_100_0) // 2^200 - 2^0
+
+	t0 = t1.square()             // 2^201 - 2^1
+	t1 = t0.square()             // 2^202 - 2^2
+	for i := 2; i < 50; i += 2 { // 2^250 - 2^50
+		t0 = t1.square()
+		t1 = t0.square()
+	}
+	t0 = t1.mult(a2_50_0) // 2^250 - 2^0
+
+	t1 = t0.square()        // 2^251 - 2^1
+	t0 = t1.square()        // 2^252 - 2^2
+	a2_252_1 := t0.mult(a2) // 2^252 - 2^1
+
+	t1 = t0.square() // 2^253 - 2^3
+	t0 = t1.mult(a2) // 2^253 - 6
+	t1 = t0.mult(a)  // 2^253 - 5
+	a2_252_1ρS := a2_252_1.mult(ρS)
+
+	out = selectFe(a2_252_1, a2_252_1ρS, t1.canonical().equalsBit(minus1))
+
+	// Check the root, the squeezed representations aren't unique
+	hasRoot = out.square().canonical().equals(a.squeeze().canonical())
+	return
+}
+
+// Computes the reciprocal of an integer (in the prime field modulo p)
+func (a fe) recip() (out fe) {
+	a2 := a.square()      // 2
+	t1 := a2.square()     // 4
+	t0 := t1.square()     // 8
+	a9 := t0.mult(a)      // 9
+	a11 := a9.mult(a2)    // 11
+	t0 = a11.square()     // 22
+	a2_5_0 := t0.mult(a9) // 2^5 - 2^0 = 31
+
+	t0 = a2_5_0.square()       // 2^6 - 2^1
+	t1 = t0.square()           // 2^7 - 2^2
+	t0 = t1.square()           // 2^8 - 2^3
+	t1 = t0.square()           // 2^9 - 2^4
+	t0 = t1.square()           // 2^10 - 2^5
+	a2_10_0 := t0.mult(a2_5_0) // 2^10 - 2^0
+
+	t0 = a2_10_0.square()        // 2^11 - 2^1
+	t1 = t0.square()             // 2^12 - 2^2
+	for i := 2; i < 10; i += 2 { // 2^20 - 2^10
+		t0 = t1.square()
+		t1 = t0.square()
+	}
+	a2_20_0 := t1.mult(a2_10_0) // 2^20 - 2^0
+
+	t0 = a2_20_0.square()        // 2^21 - 2^1
+	t1 = t0.square()             // 2^22 - 2^2
+	for i := 2; i < 20; i += 2 { // 2^40 - 2^20
+		t0 = t1.square()
+		t1 = t0.square()
+	}
+	t0 = t1.mult(a2_20_0) // 2^40 - 2^0
+
+	t1 = t0.square()             // 2^41 - 2^1
+	t0 = t1.square()             // 2^42 - 2^2
+	for i := 2; i < 10; i += 2 { // 2^50 - 2^10
+		t1 = t0.square()
+		t0 = t1.square()
+	}
+	a2_50_0 := t0.mult(a2_10_0) // 2^50 - 2^0
+
+	t0 = a2_50_0.square()        // 2^51 - 2^1
+	t1 = t0.square()             // 2^52 - 2^2
+	for i := 2; i < 50; i += 2 { // 2^100 - 2^50
+		t0 = t1.square()
+		t1 = t0.square()
+	}
+	a2_100_0 := t1.mult(a2_50_0) // 2^100 - 2^0
+
+	t1 = a2_100_0.square()        // 2^101 - 2^1
+	t0 = t1.square()              // 2^102 - 2^2
+	for i := 2; i < 100; i += 2 { // 2^200 - 2^100
+		t1 = t0.square()
+		t0 = t1.square()
+	}
+	t1 = t0.mult(a2_100_0) // 2^200 - 2^0
+
+	t0 = t1.square()             // 2^201 - 2^1
+	t1 = t0.square()             // 2^202 - 2^2
+	for i := 2; i < 50; i += 2 { // 2^250 - 2^50
+		t0 = t1.square()
+		t1 = t0.square()
+	}
+	t0 = t1.mult(a2_50_0) // 2^250 - 2^0
+
+	t1 = t0.square() // 2^251 - 2^1
+	t0 = t1.square() // 2^252 - 2^2
+	t1 = t0.square() // 2^253 - 2^3
+	t0 = t1.square() // 2^254 - 2^4
+	t1 = t0.square() // 2^255 - 2^5
+
+	return t1.mult(a11) // 2^255 - 21
+}
+
+// Computes the reciprocals of many integers with a single inversion
+// (Montgomery's simultaneous inversion trick)
+//
+// Like recip, the reciprocal of zero is zero. The inputs must be
+// squeezed.
+func feRecipBatch(in []fe) []fe {
+	out := make([]fe, len(in))
+
+	// out[i] is the product of all (non-zero) inputs before in[i]
+	acc := feOne
+	for i := range in {
+		out[i] = acc
+		acc = acc.mult(selectFe(in[i], feOne, in[i].isZeroBit()))
+	}
+
+	inv := acc.recip()
+	for i := len(in) - 1; i >= 0; i-- {
+		isZero := in[i].isZeroBit()
+		out[i] = selectFe(out[i].mult(inv), feZero, isZero)
+		inv = inv.mult(selectFe(in[i], feOne, isZero))
+	}
+	return out
+}
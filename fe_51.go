@@ -0,0 +1,308 @@
+//go:build (amd64 || arm64 || ppc64 || ppc64le || mips64 || mips64le || riscv64 || s390x) && !libuecc_generic
+// +build amd64 arm64 ppc64 ppc64le mips64 mips64le riscv64 s390x
+// +build !libuecc_generic
+
+package libuecc
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// fe is an integer modulo p = 2^255 - 19 stored in five limbs of 51
+// bits, used on 64-bit platforms
+//
+// After every operation, each limb is only slightly larger than 2^51,
+// so the representation isn't unique. Use canonical to get the fully
+// reduced value.
+type fe [5]uint64
+
+const maskLow51Bits = 1<<51 - 1
+
+// 2p in radix 2^51, added before a subtraction to avoid an underflow
+var fe2p = fe{
+	0xfffffffffffda, 0xffffffffffffe, 0xffffffffffffe,
+	0xffffffffffffe, 0xffffffffffffe,
+}
+
+// Converts an unpacked integer into its radix 2^51 representation
+func feFromUnpacked(u unpacked) fe {
+	v := u.squeeze().canonical()
+
+	var b Int256
+	for i := 0; i < 32; i++ {
+		b[i] = uint8(v[i])
+	}
+	return feFromInt256(&b)
+}
+
+// Converts a little-endian integer into its radix 2^51 representation
+//
+// The most significant bit is ignored.
+func feFromInt256(in *Int256) fe {
+	w0 := binary.LittleEndian.Uint64(in[0:])
+	w1 := binary.LittleEndian.Uint64(in[8:])
+	w2 := binary.LittleEndian.Uint64(in[16:])
+	w3 := binary.LittleEndian.Uint64(in[24:])
+
+	return fe{
+		w0 & maskLow51Bits,
+		(w0>>51 | w1<<13) & maskLow51Bits,
+		(w1>>38 | w2<<26) & maskLow51Bits,
+		(w2>>25 | w3<<39) & maskLow51Bits,
+		(w3 >> 12) & maskLow51Bits,
+	}
+}
+
+// Stores the fully reduced value of a as little-endian integer
+func (a fe) toInt256() *Int256 {
+	v := a.canonical()
+
+	out := &Int256{}
+	binary.LittleEndian.PutUint64(out[0:], v[0]|v[1]<<51)
+	binary.LittleEndian.PutUint64(out[8:], v[1]>>13|v[2]<<38)
+	binary.LittleEndian.PutUint64(out[16:], v[2]>>26|v[3]<<25)
+	binary.LittleEndian.PutUint64(out[24:], v[3]>>39|v[4]<<12)
+	return out
+}
+
+// Copies r to out when b == 0, s when b == 1
+func selectFe(r, s fe, b uint32) (out fe) {
+	bminus1 := uint64(b) - 1
+	for j := 0; j < 5; j++ {
+		out[j] = s[j] ^ (bminus1 & (r[j] ^ s[j]))
+	}
+	return
+}
+
+// Brings all limbs back to (slightly more than) 51 bits, the carry of
+// the top limb is reduced with 2^255 = 19 (modulo p)
+func (a fe) carry() fe {
+	c0 := a[0] >> 51
+	c1 := a[1] >> 51
+	c2 := a[2] >> 51
+	c3 := a[3] >> 51
+	c4 := a[4] >> 51
+
+	return fe{
+		a[0]&maskLow51Bits + c4*19,
+		a[1]&maskLow51Bits + c0,
+		a[2]&maskLow51Bits + c1,
+		a[3]&maskLow51Bits + c2,
+		a[4]&maskLow51Bits + c3,
+	}
+}
+
+// Adds two integers (modulo p)
+func (a fe) add(b fe) (out fe) {
+	for j := 0; j < 5; j++ {
+		out[j] = a[j] + b[j]
+	}
+	return out.carry()
+}
+
+// Subtracts two integers (modulo p)
+func (a fe) sub(b fe) (out fe) {
+	for j := 0; j < 5; j++ {
+		out[j] = a[j] + fe2p[j] - b[j]
+	}
+	return out.carry()
+}
+
+// Carries the limbs of an integer
+//
+// As all operations already carry their results, this only exists to
+// share the code with the 8-bit representation.
+func (a fe) squeeze() fe {
+	return a.carry()
+}
+
+// Returns the fully reduced value of a, which is unique
+func (a fe) canonical() fe {
+	a = a.carry()
+
+	// q is 1 if a >= p, 0 otherwise
+	q := (a[0] + 19) >> 51
+	q = (a[1] + q) >> 51
+	q = (a[2] + q) >> 51
+	q = (a[3] + q) >> 51
+	q = (a[4] + q) >> 51
+
+	// Subtract q*p by adding 19*q and dropping 2^255
+	a[0] += 19 * q
+	a[1] += a[0] >> 51
+	a[0] &= maskLow51Bits
+	a[2] += a[1] >> 51
+	a[1] &= maskLow51Bits
+	a[3] += a[2] >> 51
+	a[2] &= maskLow51Bits
+	a[4] += a[3] >> 51
+	a[3] &= maskLow51Bits
+	a[4] &= maskLow51Bits
+	return a
+}
+
+// Returns the parity (lowest bit of the fully reduced value) of a
+func (a fe) parity() uint32 {
+	return uint32(a.canonical()[0] & 1)
+}
+
+// Checks for the equality of two integers
+//
+// Both must be canonical.
+func (a fe) equals(b fe) bool {
+	return a.equalsBit(b) == 1
+}
+
+// Returns 1 if two integers are equal and 0 otherwise, without
+// branching on the values
+//
+// Both must be canonical.
+func (a fe) equalsBit(b fe) uint32 {
+	var differentbits uint64
+	for j := 0; j < 5; j++ {
+		differentbits |= a[j] ^ b[j]
+	}
+	return uint32((differentbits - 1) >> 63)
+}
+
+func (a fe) isZero() bool {
+	return a.isZeroBit() == 1
+}
+
+// Returns 1 if an integer is zero (modulo p) and 0 otherwise, without
+// branching on the value
+func (a fe) isZeroBit() uint32 {
+	return a.canonical().equalsBit(fe{})
+}
+
+// uint128 holds the 128 bit product of two limbs
+type uint128 struct {
+	lo, hi uint64
+}
+
+// Returns a*b
+func mul64(a, b uint64) uint128 {
+	hi, lo := bits.Mul64(a, b)
+	return uint128{lo, hi}
+}
+
+// Returns v + a*b
+func addMul64(v uint128, a, b uint64) uint128 {
+	hi, lo := bits.Mul64(a, b)
+	lo, c := bits.Add64(lo, v.lo, 0)
+	hi, _ = bits.Add64(hi, v.hi, c)
+	return uint128{lo, hi}
+}
+
+// Returns v >> 51, which must fit into 64 bits
+func (v uint128) shiftRight51() uint64 {
+	return v.hi<<13 | v.lo>>51
+}
+
+// Carries five 128 bit limbs into an integer
+//
+// The limbs must be smaller than 2^115, so the carries (multiplied by
+// 19) fit into the 64 bit limbs.
+func carryWide(r0, r1, r2, r3, r4 uint128) fe {
+	c0 := r0.shiftRight51()
+	c1 := r1.shiftRight51()
+	c2 := r2.shiftRight51()
+	c3 := r3.shiftRight51()
+	c4 := r4.shiftRight51()
+
+	out := fe{
+		r0.lo&maskLow51Bits + c4*19,
+		r1.lo&maskLow51Bits + c0,
+		r2.lo&maskLow51Bits + c1,
+		r3.lo&maskLow51Bits + c2,
+		r4.lo&maskLow51Bits + c3,
+	}
+	return out.carry()
+}
+
+// Multiplies two integers (modulo p)
+//
+// The limbs of the upper half of the product are multiplied by 19 and
+// added to the lower half, as 2^255 = 19 (modulo p).
+func (a fe) mult(b fe) fe {
+	a1_19 := a[1] * 19
+	a2_19 := a[2] * 19
+	a3_19 := a[3] * 19
+	a4_19 := a[4] * 19
+
+	r0 := mul64(a[0], b[0])
+	r0 = addMul64(r0, a1_19, b[4])
+	r0 = addMul64(r0, a2_19, b[3])
+	r0 = addMul64(r0, a3_19, b[2])
+	r0 = addMul64(r0, a4_19, b[1])
+
+	r1 := mul64(a[0], b[1])
+	r1 = addMul64(r1, a[1], b[0])
+	r1 = addMul64(r1, a2_19, b[4])
+	r1 = addMul64(r1, a3_19, b[3])
+	r1 = addMul64(r1, a4_19, b[2])
+
+	r2 := mul64(a[0], b[2])
+	r2 = addMul64(r2, a[1], b[1])
+	r2 = addMul64(r2, a[2], b[0])
+	r2 = addMul64(r2, a3_19, b[4])
+	r2 = addMul64(r2, a4_19, b[3])
+
+	r3 := mul64(a[0], b[3])
+	r3 = addMul64(r3, a[1], b[2])
+	r3 = addMul64(r3, a[2], b[1])
+	r3 = addMul64(r3, a[3], b[0])
+	r3 = addMul64(r3, a4_19, b[4])
+
+	r4 := mul64(a[0], b[4])
+	r4 = addMul64(r4, a[1], b[3])
+	r4 = addMul64(r4, a[2], b[2])
+	r4 = addMul64(r4, a[3], b[1])
+	r4 = addMul64(r4, a[4], b[0])
+
+	return carryWide(r0, r1, r2, r3, r4)
+}
+
+// Squares an integer (modulo p)
+func (a fe) square() fe {
+	d0 := a[0] * 2
+	d1 := a[1] * 2
+	a3_19 := a[3] * 19
+	a4_19 := a[4] * 19
+	a3_38 := a[3] * 38
+	a4_38 := a[4] * 38
+
+	r0 := mul64(a[0], a[0])
+	r0 = addMul64(r0, a[1], a4_38)
+	r0 = addMul64(r0, a[2], a3_38)
+
+	r1 := mul64(d0, a[1])
+	r1 = addMul64(r1, a[2], a4_38)
+	r1 = addMul64(r1, a[3], a3_19)
+
+	r2 := mul64(d0, a[2])
+	r2 = addMul64(r2, a[1], a[1])
+	r2 = addMul64(r2, a[3], a4_38)
+
+	r3 := mul64(d0, a[3])
+	r3 = addMul64(r3, d1, a[2])
+	r3 = addMul64(r3, a[4], a4_19)
+
+	r4 := mul64(d0, a[4])
+	r4 = addMul64(r4, d1, a[3])
+	r4 = addMul64(r4, a[2], a[2])
+
+	return carryWide(r0, r1, r2, r3, r4)
+}
+
+// Multiplies an integer with a small integer (modulo p)
+func (a fe) multInt(n uint32) fe {
+	return carryWide(
+		mul64(a[0], uint64(n)),
+		mul64(a[1], uint64(n)),
+		mul64(a[2], uint64(n)),
+		mul64(a[3], uint64(n)),
+		mul64(a[4], uint64(n)),
+	)
+}
@@ -0,0 +1,44 @@
+//go:build !(amd64 || arm64 || ppc64 || ppc64le || mips64 || mips64le || riscv64 || s390x) || libuecc_generic
+// +build !amd64,!arm64,!ppc64,!ppc64le,!mips64,!mips64le,!riscv64,!s390x libuecc_generic
+
+package libuecc
+
+// fe is an integer modulo p = 2^255 - 19
+//
+// On platforms without fast 64 bit multiplications (or when built with
+// the libuecc_generic tag), the portable 8-bit representation is used.
+type fe = unpacked
+
+// Converts an unpacked integer into the field representation
+func feFromUnpacked(u unpacked) fe {
+	return u
+}
+
+// Converts a little-endian integer into the field representation
+//
+// The most significant bit is ignored.
+func feFromInt256(in *Int256) (out fe) {
+	for i := 0; i < 32; i++ {
+		out[i] = uint32(in[i])
+	}
+	out[31] &= 0x7f
+	return
+}
+
+// Stores the fully reduced value of a as little-endian integer
+//
+// a must be squeezed.
+func (a unpacked) toInt256() *Int256 {
+	v := a.freeze()
+
+	out := &Int256{}
+	for i := 0; i < 32; i++ {
+		out[i] = uint8(v[i])
+	}
+	return out
+}
+
+// Copies r to out when b == 0, s when b == 1
+func selectFe(r, s fe, b uint32) fe {
+	return selectUnpacked(r, s, b)
+}
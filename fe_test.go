@@ -0,0 +1,186 @@
+package libuecc
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The same cases as in TestGeneratedData, but for the field
+// representation of the current platform, which may differ from the
+// 8-bit one of the C library. Thus only the values are compared.
+func TestGeneratedDataFe(t *testing.T) {
+	feMinusp := feFromUnpacked(minusp)
+
+	tt := map[string]func() fe{
+		"zero":   func() fe { return feZero },
+		"one":    func() fe { return feOne },
+		"minusp": func() fe { return feMinusp },
+
+		"add_0_0":      func() fe { return feZero.add(feZero) },
+		"add_0_1":      func() fe { return feZero.add(feOne) },
+		"add_1_0":      func() fe { return feOne.add(feZero) },
+		"add_1_1":      func() fe { return feOne.add(feOne) },
+		"add_0_minusp": func() fe { return feZero.add(feMinusp) },
+		"add_1_minusp": func() fe { return feOne.add(feMinusp) },
+
+		"sub_0_0":      func() fe { return feZero.sub(feZero) },
+		"sub_0_1":      func() fe { return feZero.sub(feOne) },
+		"sub_1_0":      func() fe { return feOne.sub(feZero) },
+		"sub_1_1":      func() fe { return feOne.sub(feOne) },
+		"sub_0_minusp": func() fe { return feZero.sub(feMinusp) },
+		"sub_1_minusp": func() fe { return feOne.sub(feMinusp) },
+
+		"sub_add_0_0_0": func() fe { return feZero.add(feZero).sub(feZero) },
+		"sub_add_0_0_1": func() fe { return feZero.add(feZero).sub(feOne) },
+		"sub_add_0_1_0": func() fe { return feZero.add(feOne).sub(feZero) },
+		"sub_add_0_1_1": func() fe { return feZero.add(feOne).sub(feOne) },
+		"sub_add_1_0_0": func() fe { return feOne.add(feZero).sub(feZero) },
+		"sub_add_1_0_1": func() fe { return feOne.add(feZero).sub(feOne) },
+		"sub_add_1_1_0": func() fe { return feOne.add(feOne).sub(feZero) },
+		"sub_add_1_1_1": func() fe { return feOne.add(feOne).sub(feOne) },
+
+		"add_sub_0_0_0": func() fe { return feZero.sub(feZero).add(feZero) },
+		"add_sub_0_0_1": func() fe { return feZero.sub(feZero).add(feOne) },
+		"add_sub_0_1_0": func() fe { return feZero.sub(feOne).add(feZero) },
+		"add_sub_0_1_1": func() fe { return feZero.sub(feOne).add(feOne) },
+		"add_sub_1_0_0": func() fe { return feOne.sub(feZero).add(feZero) },
+		"add_sub_1_0_1": func() fe { return feOne.sub(feZero).add(feOne) },
+		"add_sub_1_1_0": func() fe { return feOne.sub(feOne).add(feZero) },
+		"add_sub_1_1_1": func() fe { return feOne.sub(feOne).add(feOne) },
+
+		"squeeze_zero":    func() fe { return feZero.squeeze() },
+		"squeeze_one":     func() fe { return feOne.squeeze() },
+		"squeeze_sub_0_1": func() fe { return feZero.sub(feOne).squeeze() },
+
+		"mult_0_0":           func() fe { return feZero.mult(feZero) },
+		"mult_0_1":           func() fe { return feZero.mult(feOne) },
+		"mult_1_0":           func() fe { return feOne.mult(feZero) },
+		"mult_1_1":           func() fe { return feOne.mult(feOne) },
+		"mult_minusp_minusp": func() fe { return feMinusp.mult(feMinusp) },
+
+		"mult_int_0_0":   func() fe { return feZero.multInt(0) },
+		"mult_int_1_0":   func() fe { return feOne.multInt(0) },
+		"mult_int_0_1":   func() fe { return feZero.multInt(1) },
+		"mult_int_1_1":   func() fe { return feOne.multInt(1) },
+		"mult_int_0_max": func() fe { return feZero.multInt(math.MaxUint32) },
+		"mult_int_1_max": func() fe { return feOne.multInt(math.MaxUint32) },
+
+		"square_0":      func() fe { return feZero.square() },
+		"square_1":      func() fe { return feOne.square() },
+		"square_minusp": func() fe { return feMinusp.square() },
+
+		"select_0_1_0":      func() fe { return selectFe(feZero, feOne, 0) },
+		"select_0_1_1":      func() fe { return selectFe(feZero, feOne, 1) },
+		"select_0_minusp_0": func() fe { return selectFe(feZero, feMinusp, 0) },
+		"select_0_minusp_1": func() fe { return selectFe(feZero, feMinusp, 1) },
+		"select_1_minusp_0": func() fe { return selectFe(feOne, feMinusp, 0) },
+		"select_1_minusp_1": func() fe { return selectFe(feOne, feMinusp, 1) },
+	}
+
+	for fname, eval := range tt {
+		expected := feFromUnpacked(loadUnpacked("testdata/cases/" + fname)).toInt256()
+		actual := eval().toInt256()
+
+		t.Run(fname, func(t *testing.T) {
+			if *actual != *expected {
+				t.Errorf(errmsg, expected, actual)
+			}
+		})
+	}
+}
+
+func feFromBig(x *big.Int) fe {
+	var in Int256
+	b := x.Bytes()
+	for i := range b {
+		in[i] = b[len(b)-1-i]
+	}
+	return feFromInt256(&in)
+}
+
+func feToBig(a fe) *big.Int {
+	return new(big.Int).SetBytes(reverse(a.toInt256()[:]))
+}
+
+// Checks long chains of operations (so the limbs don't stay small)
+// against math/big, including the non-canonical inputs p..2^255-1
+func TestFeChains(t *testing.T) {
+	assert := assert.New(t)
+	rnd := rand.New(rand.NewSource(1))
+	max := new(big.Int).Lsh(big.NewInt(1), 255)
+	mod := func(x *big.Int) *big.Int { return x.Mod(x, bigP) }
+
+	for i := 0; i < 100; i++ {
+		x := new(big.Int).Rand(rnd, max)
+		y := new(big.Int).Rand(rnd, max)
+		if i < 19 {
+			x.Add(bigP, big.NewInt(int64(i)))
+		}
+		a, b := feFromBig(x), feFromBig(y)
+		assertBigEqual(t, mod(new(big.Int).Set(x)), feToBig(a))
+
+		ex, ac := new(big.Int).Set(x), a
+		for j := 0; j < 20; j++ {
+			ex = mod(ex.Sub(ex, y))
+			ac = ac.sub(b)
+			ex = mod(ex.Add(ex, ex))
+			ac = ac.add(ac)
+			ex = mod(ex.Mul(ex, big.NewInt(121665)))
+			ac = ac.multInt(121665)
+			ex = mod(ex.Mul(ex, y))
+			ac = ac.mult(b)
+			ex = mod(ex.Mul(ex, ex))
+			ac = ac.square()
+		}
+		assertBigEqual(t, ex, feToBig(ac))
+		assert.Equal(uint32(ex.Bit(0)), ac.parity())
+		assert.Equal(ex.Sign() == 0, ac.isZero())
+		assert.Equal(ex.Sign() == 0, ac.isZeroBit() == 1)
+
+		// equalsBit compares values, not representations
+		c := feFromBig(new(big.Int).Set(ex))
+		assert.Equal(uint32(1), ac.canonical().equalsBit(c.canonical()))
+		assert.Equal(uint32(0), ac.canonical().equalsBit(c.add(feOne).canonical()))
+	}
+}
+
+func TestFeRecipBatch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	in := make([]fe, 20)
+	for i := range in {
+		in[i] = feFromBig(new(big.Int).Rand(rnd, bigP))
+	}
+	in[0], in[7], in[19] = feZero, feFromUnpacked(p), feZero
+
+	out := feRecipBatch(in)
+	for i := range in {
+		assertBigEqual(t, feToBig(in[i].recip()), feToBig(out[i]))
+	}
+
+	assert.Empty(t, feRecipBatch(nil))
+}
+
+func BenchmarkFe(b *testing.B) {
+	x := feFromBig(new(big.Int).Sub(bigP, big.NewInt(12345)))
+	y := feFromBig(big.NewInt(121666))
+
+	b.Run("mult", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x = x.mult(y)
+		}
+	})
+	b.Run("square", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x = x.square()
+		}
+	})
+	b.Run("recip", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x = x.recip()
+		}
+	})
+}
@@ -0,0 +1,168 @@
+// Package fhmqvc implements the key derivation of the ec25519-fhmqvc
+// handshake of fastd, an authenticated key exchange based on FHMQV-C.
+//
+// Both peers have a long-term key pair (A = a*G for the initiator,
+// B = b*G for the responder) and an ephemeral handshake key pair (X and
+// Y), all public keys are packed in the legacy representation. The
+// scalars d and e are the halves of SHA256(Y || X || B || A) with the
+// most significant bit set, and both peers compute the same point
+//
+//	sigma = (x + d*a) * (Y + e*B)   (initiator)
+//	      = (y + e*b) * (X + d*A)   (responder).
+//
+// Like fastd, the secret keys are divided by 8 for the computation of
+// the scalar, and the point is multiplied with 8 to compensate.
+//
+// The shared handshake key is SHA256(Y || X || B || A || sigma). Each
+// peer proves its knowledge of it with an HMAC-SHA256 over its own
+// public keys.
+package fhmqvc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+
+	libuecc "github.com/digineo/go-libuecc"
+)
+
+// ErrIdentity is returned if the shared point is the identity element,
+// which can only happen for malicious keys
+var ErrIdentity = errors.New("fhmqvc: shared point is the identity element")
+
+// Handshake holds the public keys and the derived secrets of a handshake
+type Handshake struct {
+	// The packed legacy public keys of the initiator (A long-term, X
+	// ephemeral) and the responder (B long-term, Y ephemeral)
+	A, X, B, Y libuecc.Int256
+
+	// Sigma is the packed legacy representation of the shared point
+	Sigma libuecc.Int256
+
+	// SharedKey is the shared handshake key
+	SharedKey [sha256.Size]byte
+}
+
+// Initiator derives the shared handshake key on the initiator's side
+// from its long-term and handshake keys (a and x) and the public keys of
+// the responder (B and Y)
+func Initiator(key, handshakeKey *libuecc.PrivateKey, peer, peerHandshakeKey *libuecc.PublicKey) (*Handshake, error) {
+	h := &Handshake{}
+	copy(h.A[:], key.PublicKey().BytesLegacy())
+	copy(h.X[:], handshakeKey.PublicKey().BytesLegacy())
+	copy(h.B[:], peer.BytesLegacy())
+	copy(h.Y[:], peerHandshakeKey.BytesLegacy())
+
+	d, e := h.scalars()
+	if err := h.derive(d, e, key, handshakeKey, peer, peerHandshakeKey); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Responder derives the shared handshake key on the responder's side
+// from its long-term and handshake keys (b and y) and the public keys of
+// the initiator (A and X)
+func Responder(key, handshakeKey *libuecc.PrivateKey, peer, peerHandshakeKey *libuecc.PublicKey) (*Handshake, error) {
+	h := &Handshake{}
+	copy(h.A[:], peer.BytesLegacy())
+	copy(h.X[:], peerHandshakeKey.BytesLegacy())
+	copy(h.B[:], key.PublicKey().BytesLegacy())
+	copy(h.Y[:], handshakeKey.PublicKey().BytesLegacy())
+
+	d, e := h.scalars()
+	if err := h.derive(e, d, key, handshakeKey, peer, peerHandshakeKey); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Returns the 128 bit scalars d and e, the halves of
+// SHA256(Y || X || B || A) with the most significant bit set
+func (h *Handshake) scalars() (d, e *libuecc.Int256) {
+	sum := hashKeys(h.Y[:], h.X[:], h.B[:], h.A[:])
+
+	d, e = &libuecc.Int256{}, &libuecc.Int256{}
+	copy(d[:16], sum[:16])
+	copy(e[:16], sum[16:])
+	d[15] |= 0x80
+	e[15] |= 0x80
+	return
+}
+
+// Computes sigma = (handshakeKey + own*key) * (peerHandshakeKey +
+// other*peer) and the shared handshake key, following
+// make_shared_handshake_key() of fastd
+func (h *Handshake) derive(own, other *libuecc.Int256, key, handshakeKey *libuecc.PrivateKey, peer, peerHandshakeKey *libuecc.PublicKey) error {
+	secret := divideKey(libuecc.NewInt256(key.Bytes()))
+	handshakeSecret := divideKey(libuecc.NewInt256(handshakeKey.Bytes()))
+	s := own.GfMult(secret).GfAdd(handshakeSecret)
+
+	w := peer.Point().ScalarMult(other)
+	w.SetAdd(peerHandshakeKey.Point(), w)
+
+	// Both secrets have been divided by 8, so the point is multiplied
+	// with 8 to compensate
+	w = w.MultByCofactor()
+	w.SetScalarMult(w, s)
+	if w.IsIdentity() {
+		return ErrIdentity
+	}
+
+	h.Sigma = *w.StorePackedLegacy()
+	h.SharedKey = hashKeys(h.Y[:], h.X[:], h.B[:], h.A[:], h.Sigma[:])
+	return nil
+}
+
+// Divides a sanitized secret key by 8 like divide_key() of fastd, which
+// is exact as sanitized keys are multiples of 8
+func divideKey(k *libuecc.Int256) *libuecc.Int256 {
+	var c byte
+	for i := len(k) - 1; i >= 0; i-- {
+		c2 := k[i] << 5
+		k[i] = k[i]>>3 | c
+		c = c2
+	}
+	return k
+}
+
+// InitiatorMAC returns the HMAC-SHA256 the initiator uses to prove its
+// knowledge of the shared handshake key, authenticating A and X
+func (h *Handshake) InitiatorMAC() []byte {
+	return h.mac(h.A[:], h.X[:])
+}
+
+// ResponderMAC returns the HMAC-SHA256 the responder uses to prove its
+// knowledge of the shared handshake key, authenticating B and Y
+func (h *Handshake) ResponderMAC() []byte {
+	return h.mac(h.B[:], h.Y[:])
+}
+
+// VerifyInitiator checks the MAC received from the initiator in constant
+// time
+func (h *Handshake) VerifyInitiator(mac []byte) bool {
+	return hmac.Equal(mac, h.InitiatorMAC())
+}
+
+// VerifyResponder checks the MAC received from the responder in constant
+// time
+func (h *Handshake) VerifyResponder(mac []byte) bool {
+	return hmac.Equal(mac, h.ResponderMAC())
+}
+
+func (h *Handshake) mac(public, handshakePublic []byte) []byte {
+	m := hmac.New(sha256.New, h.SharedKey[:])
+	m.Write(public)
+	m.Write(handshakePublic)
+	return m.Sum(nil)
+}
+
+// Returns the SHA-256 digest of the concatenation of the given keys
+func hashKeys(keys ...[]byte) (sum [sha256.Size]byte) {
+	d := sha256.New()
+	for _, k := range keys {
+		d.Write(k)
+	}
+	d.Sum(sum[:0])
+	return
+}
@@ -0,0 +1,133 @@
+package fhmqvc
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	libuecc "github.com/digineo/go-libuecc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadCase(t *testing.T, name string) []byte {
+	buf, err := ioutil.ReadFile("../testdata/cases/" + name)
+	require.NoError(t, err)
+	return buf
+}
+
+func loadKey(t *testing.T, i int) *libuecc.PrivateKey {
+	k, err := libuecc.PrivateKeyFromBytes(loadCase(t, fmt.Sprintf("ecc_key_%d", i)))
+	require.NoError(t, err)
+	return k
+}
+
+func TestGeneratedData(t *testing.T) {
+	assert := assert.New(t)
+
+	// a, x, b and y are the keys of the generated data
+	a, x, b, y := loadKey(t, 0), loadKey(t, 1), loadKey(t, 2), loadKey(t, 3)
+
+	initiator, err := Initiator(a, x, b.PublicKey(), y.PublicKey())
+	require.NoError(t, err)
+	responder, err := Responder(b, y, a.PublicKey(), x.PublicKey())
+	require.NoError(t, err)
+
+	for name, h := range map[string]*Handshake{"initiator": initiator, "responder": responder} {
+		assert.Equal(loadCase(t, "fhmqvc_sigma"), h.Sigma[:], name)
+		assert.Equal(loadCase(t, "fhmqvc_shared_handshake_key"), h.SharedKey[:], name)
+		assert.Equal(loadCase(t, "fhmqvc_initiator_mac"), h.InitiatorMAC(), name)
+		assert.Equal(loadCase(t, "fhmqvc_responder_mac"), h.ResponderMAC(), name)
+	}
+}
+
+func TestFastdTranscript(t *testing.T) {
+	f, err := os.Open("../testdata/fastd/fhmqvc.txt")
+	require.NoError(t, err)
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		var v [12][]byte
+		fields := strings.Split(line, ":")
+		require.Len(t, fields, len(v))
+		for i := range v {
+			v[i], err = hex.DecodeString(fields[i])
+			require.NoError(t, err)
+		}
+
+		t.Run(fmt.Sprintf("%d", n), func(t *testing.T) {
+			assert := assert.New(t)
+
+			keys := make([]*libuecc.PrivateKey, 4)
+			pubs := make([]*libuecc.PublicKey, 4)
+			for i := range keys {
+				keys[i], err = libuecc.PrivateKeyFromBytes(v[i])
+				require.NoError(t, err)
+				assert.Equal(v[i], keys[i].Bytes(), "secret %d", i)
+
+				pubs[i], err = libuecc.PublicKeyFromBytesLegacy(v[4+i])
+				require.NoError(t, err)
+				assert.Equal(v[4+i], keys[i].PublicKey().BytesLegacy(), "public %d", i)
+			}
+
+			initiator, err := Initiator(keys[0], keys[1], pubs[2], pubs[3])
+			require.NoError(t, err)
+			responder, err := Responder(keys[2], keys[3], pubs[0], pubs[1])
+			require.NoError(t, err)
+
+			for name, h := range map[string]*Handshake{"initiator": initiator, "responder": responder} {
+				assert.Equal(v[8], h.Sigma[:], name)
+				assert.Equal(v[9], h.SharedKey[:], name)
+				assert.Equal(v[10], h.InitiatorMAC(), name)
+				assert.Equal(v[11], h.ResponderMAC(), name)
+			}
+			assert.True(responder.VerifyInitiator(v[10]))
+			assert.True(initiator.VerifyResponder(v[11]))
+		})
+		n++
+	}
+	require.NoError(t, scanner.Err())
+	require.NotZero(t, n)
+}
+
+func TestHandshake(t *testing.T) {
+	assert := assert.New(t)
+
+	keys := make([]*libuecc.PrivateKey, 4)
+	for i := range keys {
+		var err error
+		keys[i], err = libuecc.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+	}
+	a, x, b, y := keys[0], keys[1], keys[2], keys[3]
+
+	initiator, err := Initiator(a, x, b.PublicKey(), y.PublicKey())
+	require.NoError(t, err)
+	responder, err := Responder(b, y, a.PublicKey(), x.PublicKey())
+	require.NoError(t, err)
+
+	assert.Equal(initiator, responder)
+	assert.True(initiator.VerifyResponder(responder.ResponderMAC()))
+	assert.True(responder.VerifyInitiator(initiator.InitiatorMAC()))
+	assert.False(initiator.VerifyResponder(responder.InitiatorMAC()))
+
+	// a responder with another long-term key derives another key
+	c, err := libuecc.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	other, err := Responder(c, y, a.PublicKey(), x.PublicKey())
+	require.NoError(t, err)
+	assert.NotEqual(initiator.SharedKey, other.SharedKey)
+	assert.False(initiator.VerifyResponder(other.ResponderMAC()))
+}
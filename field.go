@@ -0,0 +1,102 @@
+package libuecc
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+var (
+	errFieldElementSize      = errors.New("libuecc: invalid field element length")
+	errFieldElementCanonical = errors.New("libuecc: non-canonical field element encoding")
+)
+
+// FieldElement is an element of the prime field modulo p = 2^255 - 19,
+// which the curve is defined over.
+//
+// All operations take constant time. The zero value is a valid
+// FieldElement representing zero.
+type FieldElement struct {
+	v fe // always squeezed
+}
+
+// SetBytes sets f to the value of a 32 byte little-endian encoding and
+// returns f
+//
+// An error is returned (and f is left unchanged) if the encoding is not
+// in the range [0,p-1]. In particular, the most significant bit must
+// not be set.
+func (f *FieldElement) SetBytes(b []byte) (*FieldElement, error) {
+	if len(b) != 32 {
+		return nil, errFieldElementSize
+	}
+
+	// b is canonical iff it survives a round trip through the fully
+	// reduced representation
+	v := feFromInt256(NewInt256(b))
+	if b[31]>>7 != 0 || subtle.ConstantTimeCompare(v.toInt256()[:], b) != 1 {
+		return nil, errFieldElementCanonical
+	}
+
+	f.v = v
+	return f, nil
+}
+
+// Bytes returns the canonical 32 byte little-endian encoding of f
+func (f *FieldElement) Bytes() []byte {
+	out := make([]byte, 32)
+	copy(out, f.v.toInt256()[:])
+	return out
+}
+
+// Add adds two field elements
+func (f *FieldElement) Add(o *FieldElement) *FieldElement {
+	return &FieldElement{v: f.v.add(o.v).squeeze()}
+}
+
+// Sub subtracts two field elements
+func (f *FieldElement) Sub(o *FieldElement) *FieldElement {
+	return &FieldElement{v: f.v.sub(o.v).squeeze()}
+}
+
+// Negate computes the additive inverse of a field element
+func (f *FieldElement) Negate() *FieldElement {
+	return &FieldElement{v: feZero.sub(f.v).squeeze()}
+}
+
+// Mul multiplies two field elements
+func (f *FieldElement) Mul(o *FieldElement) *FieldElement {
+	return &FieldElement{v: f.v.mult(o.v)}
+}
+
+// Square squares a field element
+func (f *FieldElement) Square() *FieldElement {
+	return &FieldElement{v: f.v.square()}
+}
+
+// Invert computes the multiplicative inverse of a field element
+//
+// The inverse of zero is zero.
+func (f *FieldElement) Invert() *FieldElement {
+	return &FieldElement{v: f.v.recip()}
+}
+
+// Sqrt computes a square root of a field element
+//
+// If f is not a square, ok is false and the value of r is unspecified.
+// Which of the two square roots is returned is unspecified as well, use
+// IsNegative and Negate to pick one.
+func (f *FieldElement) Sqrt() (r *FieldElement, ok bool) {
+	v, ok := f.v.sqrt()
+	return &FieldElement{v: v}, ok
+}
+
+// IsNegative reports whether the canonical encoding of f is odd, which
+// is how RFC 8032 defines negative field elements
+func (f *FieldElement) IsNegative() bool {
+	return f.v.parity() == 1
+}
+
+// Equal checks two field elements for equality in constant time
+func (f *FieldElement) Equal(o *FieldElement) bool {
+	return f.v.canonical().equals(o.v.canonical())
+}
@@ -0,0 +1,94 @@
+package libuecc
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bigP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+func fieldElementFromBig(t *testing.T, x *big.Int) *FieldElement {
+	b := make([]byte, 32)
+	xb := x.Bytes()
+	copy(b[32-len(xb):], xb)
+	f, err := new(FieldElement).SetBytes(reverse(b))
+	require.NoError(t, err)
+	return f
+}
+
+func fieldElementToBig(f *FieldElement) *big.Int {
+	return new(big.Int).SetBytes(reverse(f.Bytes()))
+}
+
+func assertBigEqual(t *testing.T, expected, actual *big.Int) {
+	t.Helper()
+	if expected.Cmp(actual) != 0 {
+		t.Errorf("expected %x, got %x", expected, actual)
+	}
+}
+
+func TestFieldElementSetBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	pMinus1 := reverse(new(big.Int).Sub(bigP, big.NewInt(1)).Bytes())
+	f, err := new(FieldElement).SetBytes(pMinus1)
+	require.NoError(t, err)
+	assert.Equal(pMinus1, f.Bytes())
+
+	for _, x := range []*big.Int{
+		bigP,
+		new(big.Int).Add(bigP, big.NewInt(1)),
+		new(big.Int).Lsh(big.NewInt(1), 255),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+	} {
+		_, err = new(FieldElement).SetBytes(reverse(x.Bytes()))
+		assert.Error(err, "%x", x)
+	}
+
+	_, err = new(FieldElement).SetBytes(make([]byte, 33))
+	assert.Error(err)
+}
+
+func TestFieldElementArithmetic(t *testing.T) {
+	assert := assert.New(t)
+	rnd := rand.New(rand.NewSource(1))
+	mod := func(x *big.Int) *big.Int { return x.Mod(x, bigP) }
+
+	for i := 0; i < 200; i++ {
+		x := new(big.Int).Rand(rnd, bigP)
+		y := new(big.Int).Rand(rnd, bigP)
+		if i < 20 {
+			// small values
+			x.SetInt64(int64(i))
+			y.SetInt64(int64(i * i))
+		}
+		a, b := fieldElementFromBig(t, x), fieldElementFromBig(t, y)
+
+		assertBigEqual(t, mod(new(big.Int).Add(x, y)), fieldElementToBig(a.Add(b)))
+		assertBigEqual(t, mod(new(big.Int).Sub(x, y)), fieldElementToBig(a.Sub(b)))
+		assertBigEqual(t, mod(new(big.Int).Neg(x)), fieldElementToBig(a.Negate()))
+		assertBigEqual(t, mod(new(big.Int).Mul(x, y)), fieldElementToBig(a.Mul(b)))
+		assertBigEqual(t, mod(new(big.Int).Mul(x, x)), fieldElementToBig(a.Square()))
+		assert.Equal(x.Bit(0) == 1, a.IsNegative())
+
+		if x.Sign() != 0 {
+			assertBigEqual(t, new(big.Int).ModInverse(x, bigP), fieldElementToBig(a.Invert()))
+		} else {
+			assertBigEqual(t, new(big.Int), fieldElementToBig(a.Invert()))
+		}
+
+		r, ok := a.Square().Sqrt()
+		assert.True(ok)
+		assert.True(r.Equal(a) || r.Equal(a.Negate()))
+
+		_, ok = a.Sqrt()
+		assert.Equal(new(big.Int).ModSqrt(x, bigP) != nil, ok, "sqrt(%x)", x)
+
+		assert.True(a.Equal(a.Add(b).Sub(b)))
+		assert.False(a.Equal(a.Add(fieldElementFromBig(t, big.NewInt(1)))))
+	}
+}
@@ -25,49 +25,77 @@ func gfSelect(r, s Int256, b uint32) (out Int256) {
 
 // GfIsZero checks if an integer is equal to zero (after reduction)
 func (in *Int256) GfIsZero() bool {
-	r := in.GfReduce()
+	return in.gfIsZeroBit() == 1
+}
+
+// Returns 1 if an integer is equal to zero (after reduction) and 0
+// otherwise, without branching on the value
+func (in *Int256) gfIsZeroBit() uint32 {
+	r := reduce(*in)
 	var bits uint32
 	for i := 0; i < 32; i++ {
 		bits |= uint32(r[i])
 	}
-	return ((bits-1)>>8)&1 == 1
+	return ((bits - 1) >> 8) & 1
 }
 
 // GfAdd adds two integers as Galois field elements
 func (in *Int256) GfAdd(o *Int256) *Int256 {
-	out := &Int256{}
-	nq := 1 - (in[31] >> 4) - (o[31] >> 4)
+	return new(Int256).SetGfAdd(in, o)
+}
+
+// SetGfAdd sets s to the sum a+b as Galois field elements and returns s
+//
+// Like the other Set methods, it doesn't allocate and s may be the same
+// integer as a or b.
+func (s *Int256) SetGfAdd(a, b *Int256) *Int256 {
+	var out Int256
+	nq := 1 - int32(a[31]>>4) - int32(b[31]>>4)
 
 	u := uint32(0)
 	for j := 0; j < 32; j++ {
-		u += uint32(in[j]) + uint32(o[j]) +
+		u += uint32(a[j]) + uint32(b[j]) +
 			uint32(nq)*uint32(gfOrder[j])
 		out[j] = uint8(u)
-		u >>= 8
+		u = u>>8 | (u>>31&1*math.MaxUint32)<<24
 	}
-	return out
+
+	*s = out
+	return s
 }
 
 // GfSub subtracts two integers as Galois field elements
 func (in *Int256) GfSub(o *Int256) *Int256 {
-	out := &Int256{}
-	nq := 8 - (in[31] >> 4) + (o[31] >> 4)
+	return new(Int256).SetGfSub(in, o)
+}
+
+// SetGfSub sets s to the difference a-b as Galois field elements and
+// returns s
+func (s *Int256) SetGfSub(a, b *Int256) *Int256 {
+	var out Int256
+	nq := 8 - int32(a[31]>>4) + int32(b[31]>>4)
 
 	u := uint32(0)
 	for j := 0; j < 32; j++ {
-		u += uint32(in[j]) - uint32(o[j]) + uint32(nq)*uint32(gfOrder[j])
+		u += uint32(a[j]) - uint32(b[j]) + uint32(nq)*uint32(gfOrder[j])
 		out[j] = uint8(u)
 		u = u>>8 | (u>>31&1*math.MaxUint32)<<24
 	}
 
-	return out
+	*s = out
+	return s
 }
 
 // GfReduce reduces an integer to a unique representation in the range [0,q-1]
 func (in *Int256) GfReduce() *Int256 {
-	out := reduce(*in)
-	o := Int256(out)
-	return &o
+	return new(Int256).SetGfReduce(in)
+}
+
+// SetGfReduce sets s to the unique representation of a in the range
+// [0,q-1] and returns s
+func (s *Int256) SetGfReduce(a *Int256) *Int256 {
+	*s = reduce(*a)
+	return s
 }
 
 // Reduces an integer to a unique representation in the range [0,q-1]
@@ -96,6 +124,23 @@ func (in *Int256) reduce() {
 	*in = reduce(*in)
 }
 
+// GfReduceWide reduces a 512 bit little-endian integer to a unique
+// representation in the range [0,q-1]
+//
+// This can be used to map the output of a 512 bit hash function (like
+// SHA-512) to a Galois field element with a negligible bias.
+func GfReduceWide(b [64]byte) *Int256 {
+	var lo, hi Int256
+	copy(lo[:], b[:32])
+	copy(hi[:], b[32:])
+
+	// The Montgomery multiplication divides by 2^256, so this is
+	// hi * 2^256 (mod q)
+	hi = montgomery(hi, _2_512modq)
+
+	return lo.GfAdd(&hi).GfReduce()
+}
+
 // Montgomery modular multiplication algorithm
 func montgomery(a, b Int256) (out Int256) {
 	for i := 0; i < 32; i++ {
@@ -123,16 +168,28 @@ var _2_512modq = Int256{ // 2^512 mod q
 
 // GfMult multiplies two integers as Galois field elements
 func (in *Int256) GfMult(o *Int256) *Int256 {
-	b := o
-	b.reduce()
+	return new(Int256).SetGfMult(in, o)
+}
+
+// SetGfMult sets s to the product a*b as Galois field elements and
+// returns s
+func (s *Int256) SetGfMult(a, b *Int256) *Int256 {
+	c := *b
+	c.reduce()
 
-	r := montgomery(*in, *b)
-	out := montgomery(r, _2_512modq)
-	return &out
+	r := montgomery(*a, c)
+	*s = montgomery(r, _2_512modq)
+	return s
 }
 
 // GfRecip computes the reciprocal of a Galois field element
 func (in *Int256) GfRecip() *Int256 {
+	return new(Int256).SetGfRecip(in)
+}
+
+// SetGfRecip sets s to the reciprocal of a as Galois field element and
+// returns s
+func (s *Int256) SetGfRecip(in *Int256) *Int256 {
 	var b, r1, r2 Int256
 
 	r1[0] = 1
@@ -172,8 +229,41 @@ func (in *Int256) GfRecip() *Int256 {
 		}
 	}
 
-	out := montgomery(r2, Int256{1})
-	return &out
+	*s = montgomery(r2, Int256{1})
+	return s
+}
+
+// GfRecipBatch computes the reciprocals of many Galois field elements
+//
+// This gives the same values as calling GfRecip for every element (in
+// particular, the reciprocal of zero is zero), but needs only a single
+// inversion (Montgomery's simultaneous inversion trick). The results
+// are fully reduced, and the time needed only depends on the number of
+// elements.
+func GfRecipBatch(in []*Int256) []*Int256 {
+	// prefix[i] is the product of all (non-zero) elements before in[i]
+	prefix := make([]Int256, len(in))
+	acc := Int256{1}
+	for i, a := range in {
+		prefix[i] = acc
+		nonZero := gfSelect(*a, Int256{1}, a.gfIsZeroBit())
+		acc.SetGfMult(&acc, &nonZero)
+	}
+
+	acc.SetGfRecip(&acc)
+
+	out := make([]*Int256, len(in))
+	for i := len(in) - 1; i >= 0; i-- {
+		isZero := in[i].gfIsZeroBit()
+		nonZero := gfSelect(*in[i], Int256{1}, isZero)
+
+		r := new(Int256).SetGfMult(&prefix[i], &acc)
+		*r = gfSelect(*r.SetGfReduce(r), Int256{}, isZero)
+		out[i] = r
+
+		acc.SetGfMult(&acc, &nonZero)
+	}
+	return out
 }
 
 // SanitizeSecret Ensures some properties of a Galois field element to
@@ -2,6 +2,8 @@ package libuecc
 
 import (
 	"encoding/hex"
+	"math/big"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -89,3 +91,129 @@ func TestInt256_GfSub(t *testing.T) {
 		})
 	}
 }
+
+// reverse returns a reversed copy of a byte slice, to convert between
+// little-endian Int256 values and big-endian math/big input
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+func toBig(n *Int256) *big.Int {
+	return new(big.Int).SetBytes(reverse(n[:]))
+}
+
+func TestInt256_GfAddSubBig(t *testing.T) {
+	assert := assert.New(t)
+	q := toBig(&gfOrder)
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		a, b := &Int256{}, &Int256{}
+		rnd.Read(a[:])
+		rnd.Read(b[:])
+
+		sum := new(big.Int).Add(toBig(a), toBig(b))
+		assert.Equal(sum.Mod(sum, q), toBig(a.GfAdd(b).GfReduce()), "%x + %x", a[:], b[:])
+
+		diff := new(big.Int).Sub(toBig(a), toBig(b))
+		assert.Equal(diff.Mod(diff, q), toBig(a.GfSub(b).GfReduce()), "%x - %x", a[:], b[:])
+	}
+}
+
+func TestGfReduceWide(t *testing.T) {
+	assert := assert.New(t)
+	q := toBig(&gfOrder)
+	rnd := rand.New(rand.NewSource(1))
+
+	inputs := [][64]byte{{}, {1}}
+	max := [64]byte{}
+	for i := range max {
+		max[i] = 0xff
+	}
+	inputs = append(inputs, max)
+	for i := 0; i < 1000; i++ {
+		var b [64]byte
+		rnd.Read(b[:])
+		inputs = append(inputs, b)
+	}
+
+	for _, b := range inputs {
+		expected := new(big.Int).SetBytes(reverse(b[:]))
+		expected.Mod(expected, q)
+
+		assert.Equal(expected, toBig(GfReduceWide(b)), "%x", b[:])
+	}
+}
+
+func TestInt256_GfMultKeepsArgument(t *testing.T) {
+	a := testScalars()[4]
+	b := *a
+
+	a.GfMult(&b)
+	assert.Equal(t, *a, b)
+}
+
+func TestInt256_SetGfMethods(t *testing.T) {
+	scalars := testScalars()
+	a, b := scalars[3], scalars[4]
+
+	tt := map[string]struct {
+		expected *Int256
+		set      func(s *Int256) *Int256
+	}{
+		"add":      {a.GfAdd(b), func(s *Int256) *Int256 { return s.SetGfAdd(s, b) }},
+		"add_self": {a.GfAdd(a), func(s *Int256) *Int256 { return s.SetGfAdd(s, s) }},
+		"sub":      {a.GfSub(b), func(s *Int256) *Int256 { return s.SetGfSub(s, b) }},
+		"mult":     {a.GfMult(b), func(s *Int256) *Int256 { return s.SetGfMult(s, b) }},
+		"square":   {a.GfMult(a), func(s *Int256) *Int256 { return s.SetGfMult(s, s) }},
+		"recip":    {a.GfRecip(), func(s *Int256) *Int256 { return s.SetGfRecip(s) }},
+		"reduce":   {a.GfReduce(), func(s *Int256) *Int256 { return s.SetGfReduce(s) }},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			s := &Int256{}
+			*s = *a
+			assert.True(tc.set(s) == s, "receiver not returned")
+			assert.Equal(*tc.expected, *s)
+
+			allocs := testing.AllocsPerRun(10, func() {
+				*s = *a
+				tc.set(s)
+			})
+			assert.Zero(allocs)
+		})
+	}
+}
+
+func TestGfRecipBatch(t *testing.T) {
+	assert := assert.New(t)
+	q := toBig(&gfOrder)
+
+	in := testScalars()
+	in = append(in, &Int256{}, &gfOrder, in[1].GfAdd(&gfOrder))
+	in[2] = &Int256{}
+
+	out := GfRecipBatch(in)
+	require.Len(t, out, len(in))
+
+	for i, a := range in {
+		assert.Equal(*a.GfRecip().GfReduce(), *out[i], "%x", a[:])
+
+		x := new(big.Int).Mod(toBig(a), q)
+		assert.Equal(x.Sign() == 0, a.gfIsZeroBit() == 1, "%x", a[:])
+		if x.Sign() == 0 {
+			assert.Equal(Int256{}, *out[i])
+		} else {
+			assert.Equal(new(big.Int).ModInverse(x, q), toBig(out[i]))
+		}
+	}
+
+	assert.Empty(GfRecipBatch(nil))
+}
@@ -0,0 +1,112 @@
+package libuecc
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+var (
+	errScalarSize      = errors.New("libuecc: invalid scalar length")
+	errScalarCanonical = errors.New("libuecc: non-canonical scalar encoding")
+)
+
+// Scalar is an integer modulo the group order
+// q = 2^252 + 27742317777372353535851937790883648493.
+//
+// In contrast to the Gf* methods of Int256, a Scalar is always fully
+// reduced, and its methods don't accept non-canonical encodings. The
+// zero value is a valid Scalar representing zero.
+type Scalar struct {
+	v Int256
+}
+
+// Returns a new Scalar from an integer which is already reduced
+func newScalar(v *Int256) *Scalar {
+	return &Scalar{v: *v}
+}
+
+// SetCanonicalBytes sets s to the value of a 32 byte little-endian
+// encoding and returns s
+//
+// An error is returned (and s is left unchanged) if the encoding is not
+// in the range [0,q-1].
+func (s *Scalar) SetCanonicalBytes(b []byte) (*Scalar, error) {
+	if len(b) != 32 {
+		return nil, errScalarSize
+	}
+
+	v := NewInt256(b)
+	if subtle.ConstantTimeCompare(v[:], v.GfReduce()[:]) != 1 {
+		return nil, errScalarCanonical
+	}
+	s.v = *v
+	return s, nil
+}
+
+// SetUniformBytes sets s to the value of a 64 byte little-endian
+// integer modulo q and returns s
+//
+// If the input is uniformly distributed (like the output of SHA-512),
+// the result will be uniformly distributed as well (with a negligible
+// bias).
+func (s *Scalar) SetUniformBytes(b []byte) (*Scalar, error) {
+	if len(b) != 64 {
+		return nil, errScalarSize
+	}
+
+	var wide [64]byte
+	copy(wide[:], b)
+	s.v = *GfReduceWide(wide)
+	return s, nil
+}
+
+// SetInt256 sets s to the value of an integer modulo q and returns s
+func (s *Scalar) SetInt256(n *Int256) *Scalar {
+	s.v = *n.GfReduce()
+	return s
+}
+
+// Int256 returns the value of a Scalar as integer in the range [0,q-1]
+func (s *Scalar) Int256() *Int256 {
+	out := s.v
+	return &out
+}
+
+// Bytes returns the canonical 32 byte little-endian encoding of a Scalar
+func (s *Scalar) Bytes() []byte {
+	out := make([]byte, 32)
+	copy(out, s.v[:])
+	return out
+}
+
+// Add adds two Scalars
+func (s *Scalar) Add(o *Scalar) *Scalar {
+	return newScalar(s.v.GfAdd(&o.v).GfReduce())
+}
+
+// Sub subtracts two Scalars
+func (s *Scalar) Sub(o *Scalar) *Scalar {
+	return newScalar(s.v.GfSub(&o.v).GfReduce())
+}
+
+// Mul multiplies two Scalars
+func (s *Scalar) Mul(o *Scalar) *Scalar {
+	return newScalar(s.v.GfMult(&o.v).GfReduce())
+}
+
+// Negate computes the additive inverse of a Scalar
+func (s *Scalar) Negate() *Scalar {
+	return newScalar((&Int256{}).GfSub(&s.v).GfReduce())
+}
+
+// Invert computes the multiplicative inverse of a Scalar
+//
+// The inverse of zero is zero.
+func (s *Scalar) Invert() *Scalar {
+	return newScalar(s.v.GfRecip().GfReduce())
+}
+
+// Equal checks two Scalars for equality in constant time
+func (s *Scalar) Equal(o *Scalar) bool {
+	return subtle.ConstantTimeCompare(s.v[:], o.v[:]) == 1
+}
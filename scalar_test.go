@@ -0,0 +1,72 @@
+package libuecc
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomScalar(rnd *rand.Rand) *Scalar {
+	var b [64]byte
+	rnd.Read(b[:])
+	s, err := new(Scalar).SetUniformBytes(b[:])
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestScalarSetCanonicalBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	qMinus1 := gfOrder
+	qMinus1[0]--
+	s, err := new(Scalar).SetCanonicalBytes(qMinus1[:])
+	require.NoError(t, err)
+	assert.Equal(qMinus1[:], s.Bytes())
+
+	qPlus1 := gfOrder
+	qPlus1[0]++
+	for _, b := range [][]byte{gfOrder[:], qPlus1[:], testScalars()[4][:]} {
+		_, err = new(Scalar).SetCanonicalBytes(b)
+		assert.Error(err, "%x", b)
+	}
+
+	_, err = new(Scalar).SetCanonicalBytes(make([]byte, 31))
+	assert.Error(err)
+	_, err = new(Scalar).SetUniformBytes(make([]byte, 32))
+	assert.Error(err)
+}
+
+func TestScalarArithmetic(t *testing.T) {
+	assert := assert.New(t)
+	q := toBig(&gfOrder)
+	rnd := rand.New(rand.NewSource(1))
+
+	mod := func(x *big.Int) *big.Int { return x.Mod(x, q) }
+
+	for i := 0; i < 100; i++ {
+		a, b := randomScalar(rnd), randomScalar(rnd)
+		x, y := toBig(a.Int256()), toBig(b.Int256())
+
+		assert.Equal(mod(new(big.Int).Add(x, y)), toBig(a.Add(b).Int256()))
+		assert.Equal(mod(new(big.Int).Sub(x, y)), toBig(a.Sub(b).Int256()))
+		assert.Equal(mod(new(big.Int).Mul(x, y)), toBig(a.Mul(b).Int256()))
+		assert.Equal(mod(new(big.Int).Neg(x)), toBig(a.Negate().Int256()))
+		assert.Equal(new(big.Int).ModInverse(x, q), toBig(a.Invert().Int256()))
+
+		assert.True(a.Equal(a.Add(b).Sub(b)))
+		assert.False(a.Equal(a.Add(&Scalar{v: Int256{1}})))
+
+		c, err := new(Scalar).SetCanonicalBytes(a.Bytes())
+		require.NoError(t, err)
+		assert.True(a.Equal(c))
+	}
+
+	zero := &Scalar{}
+	assert.True(zero.Equal(zero.Invert()))
+	assert.True(zero.Equal(zero.Negate()))
+}
@@ -3,12 +3,17 @@
 #include <errno.h>
 #include <error.h>
 #include <inttypes.h>
+#include <stdarg.h>
+#include <stdbool.h>
 #include <stdint.h>
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
 #include <unistd.h>
 
+#include <openssl/hmac.h>
+#include <openssl/sha.h>
+
 #include "../../libuecc/src/ec25519.c"
 
 void saveQuad(FILE *f, uint32_t word) {
@@ -68,6 +73,177 @@ void saveWork(const char *filename, const ecc_25519_work_t *p) {
 	#undef saveElem
 }
 
+/* The following is a port of the key handling and of
+ * make_shared_handshake_key() of fastd's ec25519-fhmqvc method, with the
+ * configuration passed explicitly and OpenSSL for the hashes */
+
+typedef struct keypair {
+	ecc_int256_t secret;
+	ecc_int256_t public;
+} keypair_t;
+
+/* fastd_sha256_blocks(): SHA-256 of a NULL-terminated list of 32 byte blocks */
+static void sha256_blocks(ecc_int256_t *out, ...) {
+	SHA256_CTX ctx;
+	SHA256_Init(&ctx);
+
+	va_list ap;
+	va_start(ap, out);
+	const uint8_t *in;
+	while ((in = va_arg(ap, const uint8_t *)) != NULL) {
+		SHA256_Update(&ctx, in, 32);
+	}
+	va_end(ap);
+
+	SHA256_Final(out->p, &ctx);
+}
+
+/* fastd_hmacsha256_blocks(): HMAC-SHA256 of a NULL-terminated list of 32 byte blocks */
+static void hmacsha256_blocks(ecc_int256_t *out, const ecc_int256_t *key, ...) {
+	HMAC_CTX *ctx = HMAC_CTX_new();
+	if (ctx == NULL || !HMAC_Init_ex(ctx, key->p, 32, EVP_sha256(), NULL)) {
+		error(1, 0, "failed to initialize HMAC");
+	}
+
+	va_list ap;
+	va_start(ap, key);
+	const uint8_t *in;
+	while ((in = va_arg(ap, const uint8_t *)) != NULL) {
+		HMAC_Update(ctx, in, 32);
+	}
+	va_end(ap);
+
+	HMAC_Final(ctx, out->p, NULL);
+	HMAC_CTX_free(ctx);
+}
+
+/* Divides a secret key by 8 (for some optimizations) */
+static inline void divide_key(ecc_int256_t *key) {
+	uint8_t c = 0, c2;
+	ssize_t i;
+
+	for (i = 31; i >= 0; i--) {
+		c2 = key->p[i] << 5;
+		key->p[i] = (key->p[i] >> 3) | c;
+		c = c2;
+	}
+}
+
+/* Multiplies a point by 8 */
+static inline void octuple_point(ecc_25519_work_t *p) {
+	ecc_25519_work_t work;
+	ecc_25519_double(&work, p);
+	ecc_25519_double(&work, &work);
+	ecc_25519_double(p, &work);
+}
+
+/* Loads a secret key like fastd: it is sanitized, the public key is
+ * derived and the secret is divided by 8 afterwards */
+static void fhmqvc_key(keypair_t *key, const ecc_int256_t *secret) {
+	ecc_25519_work_t work;
+
+	ecc_25519_gf_sanitize_secret(&key->secret, secret);
+	ecc_25519_scalarmult_bits(&work, &key->secret, &ecc_25519_work_base_legacy, 256);
+	ecc_25519_store_packed_legacy(&key->public, &work);
+
+	divide_key(&key->secret);
+}
+
+/* make_shared_handshake_key() of fastd */
+static int make_shared_handshake_key(bool initiator, const keypair_t *key, const keypair_t *handshake_key,
+		const ecc_int256_t *peer_key, const ecc_int256_t *peer_handshake_key,
+		ecc_int256_t *sigma, ecc_int256_t *shared_handshake_key) {
+	ecc_25519_work_t work, workXY;
+
+	if (!ecc_25519_load_packed_legacy(&workXY, peer_handshake_key))
+		return 0;
+
+	if (!ecc_25519_load_packed_legacy(&work, peer_key))
+		return 0;
+
+	const ecc_int256_t *A, *B, *X, *Y;
+	if (initiator) {
+		A = &key->public;
+		B = peer_key;
+		X = &handshake_key->public;
+		Y = peer_handshake_key;
+	} else {
+		A = peer_key;
+		B = &key->public;
+		X = peer_handshake_key;
+		Y = &handshake_key->public;
+	}
+
+	ecc_int256_t hash;
+	sha256_blocks(&hash, Y->p, X->p, B->p, A->p, NULL);
+
+	ecc_int256_t d = {{0}}, e = {{0}}, s;
+
+	memcpy(d.p, hash.p, 16);
+	memcpy(e.p, hash.p+16, 16);
+
+	d.p[15] |= 0x80;
+	e.p[15] |= 0x80;
+
+	if (initiator) {
+		ecc_int256_t da;
+		ecc_25519_gf_mult(&da, &d, &key->secret);
+		ecc_25519_gf_add(&s, &da, &handshake_key->secret);
+
+		ecc_25519_scalarmult_bits(&work, &e, &work, 128);
+	} else {
+		ecc_int256_t eb;
+		ecc_25519_gf_mult(&eb, &e, &key->secret);
+		ecc_25519_gf_add(&s, &eb, &handshake_key->secret);
+
+		ecc_25519_scalarmult_bits(&work, &d, &work, 128);
+	}
+
+	ecc_25519_add(&work, &workXY, &work);
+
+	/*
+	  Both our secret keys have been divided by 8 before, so we multiply
+	  the point with 8 here to compensate.
+	*/
+	octuple_point(&work);
+
+	ecc_25519_scalarmult_bits(&work, &s, &work, 256);
+	if (ecc_25519_is_identity(&work))
+		return 0;
+
+	ecc_25519_store_packed_legacy(sigma, &work);
+
+	sha256_blocks(shared_handshake_key, Y->p, X->p, B->p, A->p, sigma->p, NULL);
+
+	return 1;
+}
+
+void fhmqvc(const ecc_int256_t keys[4]) {
+	keypair_t a, x, b, y;
+	fhmqvc_key(&a, &keys[0]);
+	fhmqvc_key(&x, &keys[1]);
+	fhmqvc_key(&b, &keys[2]);
+	fhmqvc_key(&y, &keys[3]);
+
+	ecc_int256_t sigma, key, sigmaResponder, keyResponder, mac;
+	if (!make_shared_handshake_key(true, &a, &x, &b.public, &y.public, &sigma, &key) ||
+	    !make_shared_handshake_key(false, &b, &y, &a.public, &x.public, &sigmaResponder, &keyResponder)) {
+		error(1, 0, "fhmqvc: sigma is the identity");
+	}
+	if (memcmp(key.p, keyResponder.p, 32) != 0) {
+		error(1, 0, "fhmqvc: initiator and responder disagree");
+	}
+	saveInt256("cases/fhmqvc_sigma", &sigma);
+	saveInt256("cases/fhmqvc_shared_handshake_key", &key);
+
+	/* each side authenticates its own public keys */
+	hmacsha256_blocks(&mac, &key, a.public.p, x.public.p, NULL);
+	saveInt256("cases/fhmqvc_initiator_mac", &mac);
+
+	hmacsha256_blocks(&mac, &key, b.public.p, y.public.p, NULL);
+	saveInt256("cases/fhmqvc_responder_mac", &mac);
+}
+
 void copy(uint32_t dst[32], const uint32_t src[32]) {
 	for (int i = 0; i < 32; ++i) {
 		dst[i] = src[i];
@@ -269,10 +445,26 @@ int main(int argc, char const *argv[]) {
 			ecc_25519_scalarmult_bits(&work, &key, &ecc_25519_work_base_legacy, 256);
 			ecc_25519_store_packed_legacy(&pub, &work);
 			saveInt256(filename, &pub);
+
+			ecc_int256_t x, y;
+			ecc_25519_store_xy_legacy(&x, &y, &work);
+			snprintf(filename, flen, "cases/ecc_key_xy_legacy_x_%d", i);
+			saveInt256(filename, &x);
+			snprintf(filename, flen, "cases/ecc_key_xy_legacy_y_%d", i);
+			saveInt256(filename, &y);
+
+			ecc_25519_store_xy_ed25519(&x, &y, &work);
+			snprintf(filename, flen, "cases/ecc_key_xy_ed25519_x_%d", i);
+			saveInt256(filename, &x);
+			snprintf(filename, flen, "cases/ecc_key_xy_ed25519_y_%d", i);
+			saveInt256(filename, &y);
 		}
 	}
 	free(filename);
 
+	fprintf(stderr, " fhmqvc");
+	fhmqvc(testKeys);
+
 	puts("\ndone.");
 	return 0;
 }
@@ -28,15 +28,15 @@ func (in *Int256) Bytes() []uint8 {
 // The internal representation of an unpacked Point isn't unique, so for
 // serialization it should always be packed.
 type Point struct {
-	X, Y, Z, T unpacked
+	X, Y, Z, T fe
 }
 
 // pointIdentity is the identity element
 var pointIdentity = Point{
-	X: unpacked{0},
-	Y: unpacked{1},
-	Z: unpacked{1},
-	T: unpacked{0},
+	X: feFromUnpacked(unpacked{0}),
+	Y: feFromUnpacked(unpacked{1}),
+	Z: feFromUnpacked(unpacked{1}),
+	T: feFromUnpacked(unpacked{0}),
 }
 
 // pointBaseEd25519 is the generator point used by Ed25519. It is the
@@ -44,25 +44,25 @@ var pointIdentity = Point{
 //
 // The order of the base point is 2^252 + 27742317777372353535851937790883648493.
 var pointBaseEd25519 = Point{
-	X: unpacked{
+	X: feFromUnpacked(unpacked{
 		0x1a, 0xd5, 0x25, 0x8f, 0x60, 0x2d, 0x56, 0xc9,
 		0xb2, 0xa7, 0x25, 0x95, 0x60, 0xc7, 0x2c, 0x69,
 		0x5c, 0xdc, 0xd6, 0xfd, 0x31, 0xe2, 0xa4, 0xc0,
 		0xfe, 0x53, 0x6e, 0xcd, 0xd3, 0x36, 0x69, 0x21,
-	},
-	Y: unpacked{
+	}),
+	Y: feFromUnpacked(unpacked{
 		0x58, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
 		0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
 		0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
 		0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
-	},
-	Z: unpacked{1},
-	T: unpacked{
+	}),
+	Z: feFromUnpacked(unpacked{1}),
+	T: feFromUnpacked(unpacked{
 		0xa3, 0xdd, 0xb7, 0xa5, 0xb3, 0x8a, 0xde, 0x6d,
 		0xf5, 0x52, 0x51, 0x77, 0x80, 0x9f, 0xf0, 0x20,
 		0x7d, 0xe3, 0xab, 0x64, 0x8e, 0x4e, 0xea, 0x66,
 		0x65, 0x76, 0x8b, 0xd7, 0x0f, 0x5f, 0x87, 0x67,
-	},
+	}),
 }
 
 // pointBaseLegacy is the ec25519 legacy generator point. It is the
@@ -73,25 +73,25 @@ var pointBaseEd25519 = Point{
 //
 // The order of the base point is 2^252 + 27742317777372353535851937790883648493.
 var pointBaseLegacy = Point{
-	X: unpacked{
+	X: feFromUnpacked(unpacked{
 		0x1a, 0xd5, 0x25, 0x8f, 0x60, 0x2d, 0x56, 0xc9,
 		0xb2, 0xa7, 0x25, 0x95, 0x60, 0xc7, 0x2c, 0x69,
 		0x5c, 0xdc, 0xd6, 0xfd, 0x31, 0xe2, 0xa4, 0xc0,
 		0xfe, 0x53, 0x6e, 0xcd, 0xd3, 0x36, 0x69, 0x21,
-	},
-	Y: unpacked{
+	}),
+	Y: feFromUnpacked(unpacked{
 		0x58, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
 		0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
 		0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
 		0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
-	},
-	Z: unpacked{1},
-	T: unpacked{
+	}),
+	Z: feFromUnpacked(unpacked{1}),
+	T: feFromUnpacked(unpacked{
 		0xa3, 0xdd, 0xb7, 0xa5, 0xb3, 0x8a, 0xde, 0x6d,
 		0xf5, 0x52, 0x51, 0x77, 0x80, 0x9f, 0xf0, 0x20,
 		0x7d, 0xe3, 0xab, 0x64, 0x8e, 0x4e, 0xea, 0x66,
 		0x65, 0x76, 0x8b, 0xd7, 0x0f, 0x5f, 0x87, 0x67,
-	},
+	}),
 }
 
 // PointBaseEd25519 returns a pointer to a copy of the generator point
@@ -127,21 +127,21 @@ var minusp = unpacked{
 
 // Factor to multiply the X coordinate with to convert from the legacy
 // to the Ed25519 curve
-var legacyToEd25519 = unpacked{
+var legacyToEd25519 = feFromUnpacked(unpacked{
 	0xe7, 0x81, 0xba, 0x00, 0x55, 0xfb, 0x91, 0x33,
 	0x7d, 0xe5, 0x82, 0xb4, 0x2e, 0x2c, 0x5e, 0x3a,
 	0x81, 0xb0, 0x03, 0xfc, 0x23, 0xf7, 0x84, 0x2d,
 	0x44, 0xf9, 0x5f, 0x9f, 0x0b, 0x12, 0xd9, 0x70,
-}
+})
 
 // Factor to multiply the X coordinate with to convert from the Ed25519
 // to the legacy curve
-var ed25519ToLegacy = unpacked{
+var ed25519ToLegacy = feFromUnpacked(unpacked{
 	0xe9, 0x68, 0x42, 0xdb, 0xaf, 0x04, 0xb4, 0x40,
 	0xa1, 0xd5, 0x43, 0xf2, 0xf9, 0x38, 0x31, 0x28,
 	0x01, 0x17, 0x05, 0x67, 0x9b, 0x81, 0x61, 0xf8,
 	0xa9, 0x5b, 0x3e, 0x6a, 0x20, 0x67, 0x4b, 0x24,
-}
+})
 
 // Adds two unpacked integers (modulo p)
 func (a unpacked) add(b unpacked) (out unpacked) {
@@ -219,6 +219,18 @@ func (a unpacked) freeze() (out unpacked) {
 	return
 }
 
+// Returns the fully reduced value of a squeezed unpacked integer, with
+// only the lower byte of each integer part set
+//
+// In contrast to the output of freeze, this representation is unique.
+func (a unpacked) canonical() (out unpacked) {
+	out = a.freeze()
+	for j := 0; j < 32; j++ {
+		out[j] &= 0xff
+	}
+	return
+}
+
 // Returns the parity (lowest bit of the fully reduced value) of a
 //
 // The input must be squeezed.
@@ -299,6 +311,12 @@ func (a unpacked) square() (out unpacked) {
 
 // Checks for the equality of two unpacked integers
 func (a unpacked) equals(b unpacked) bool {
+	return a.equalsBit(b) == 1
+}
+
+// Returns 1 if two unpacked integers are equal and 0 otherwise, without
+// branching on the values
+func (a unpacked) equalsBit(b unpacked) uint32 {
 	differentbits := uint32(0)
 
 	for i := 0; i < 32; i++ {
@@ -306,7 +324,7 @@ func (a unpacked) equals(b unpacked) bool {
 		differentbits |= ((a[i] ^ b[i]) >> 16)
 	}
 
-	return (1 & ((differentbits - 1) >> 16)) == 1
+	return 1 & ((differentbits - 1) >> 16)
 }
 
 var p = unpacked{
@@ -317,26 +335,23 @@ var p = unpacked{
 }
 
 func (a unpacked) isZero() bool {
-	return a.equals(zero) || a.equals(p)
+	return a.isZeroBit() == 1
 }
 
-// Copies r to out when b == 0, s when b == 1
-func selectPoint(r, s *Point, b uint32) (out Point) {
-	bminus1 := b - 1
-	for j := 0; j < 32; j++ {
-		t := bminus1 & (r.X[j] ^ s.X[j])
-		out.X[j] = s.X[j] ^ t
-
-		t = bminus1 & (r.Y[j] ^ s.Y[j])
-		out.Y[j] = s.Y[j] ^ t
-
-		t = bminus1 & (r.Z[j] ^ s.Z[j])
-		out.Z[j] = s.Z[j] ^ t
+// Returns 1 if a squeezed unpacked integer is zero (modulo p) and 0
+// otherwise, without branching on the value
+func (a unpacked) isZeroBit() uint32 {
+	return a.equalsBit(zero) | a.equalsBit(p)
+}
 
-		t = bminus1 & (r.T[j] ^ s.T[j])
-		out.T[j] = s.T[j] ^ t
+// Copies r to out when b == 0, s when b == 1
+func selectPoint(r, s *Point, b uint32) Point {
+	return Point{
+		X: selectFe(r.X, s.X, b),
+		Y: selectFe(r.Y, s.Y, b),
+		Z: selectFe(r.Z, s.Z, b),
+		T: selectFe(r.T, s.T, b),
 	}
-	return
 }
 
 // Copies r to out when b == 0, s when b == 1
@@ -349,196 +364,21 @@ func selectUnpacked(r, s unpacked, b uint32) (out unpacked) {
 	return
 }
 
-var minus1 = unpacked{
-	0xec, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
-	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
-	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
-	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
-}
-
-var ρS = unpacked{ // rho_s
-	0xb0, 0xa0, 0x0e, 0x4a, 0x27, 0x1b, 0xee, 0xc4,
-	0x78, 0xe4, 0x2f, 0xad, 0x06, 0x18, 0x43, 0x2f,
-	0xa7, 0xd7, 0xfb, 0x3d, 0x99, 0x00, 0x4d, 0x2b,
-	0x0b, 0xdf, 0xc1, 0x4f, 0x80, 0x24, 0x83, 0x2b,
-}
-
-// Computes the square root of an unpacked integer (in the prime field modulo p)
-//
-// If the given integer has no square root, hasRoot=false is returned
-func (a unpacked) sqrt() (out unpacked, hasRoot bool) {
-	// raise z to power (2^252-2), check if power (2^253-5) equals -1
-	a2 := a.square()      // 2
-	t1 := a2.square()     // 4
-	t0 := t1.square()     // 8
-	a9 := t0.mult(a)      // 9
-	a11 := a9.mult(a2)    // 11
-	t0 = a11.square()     // 22
-	a2_5_0 := t0.mult(a9) // 2^5 - 2^0 = 31
-
-	t0 = a2_5_0.square()       // 2^6 - 2^1
-	t1 = t0.square()           // 2^7 - 2^2
-	t0 = t1.square()           // 2^8 - 2^3
-	t1 = t0.square()           // 2^9 - 2^4
-	t0 = t1.square()           // 2^10 - 2^5
-	a2_10_0 := t0.mult(a2_5_0) // 2^10 - 2^0
-
-	t0 = a2_10_0.square()        // 2^11 - 2^1
-	t1 = t0.square()             // 2^12 - 2^2
-	for i := 2; i < 10; i += 2 { // 2^20 - 2^10
-		t0 = t1.square()
-		t1 = t0.square()
-	}
-	a2_20_0 := t1.mult(a2_10_0) // 2^20 - 2^0
-
-	t0 = a2_20_0.square()        // 2^21 - 2^1
-	t1 = t0.square()             // 2^22 - 2^2
-	for i := 2; i < 20; i += 2 { // 2^40 - 2^20
-		t0 = t1.square()
-		t1 = t0.square()
-	}
-	t0 = t1.mult(a2_20_0) // 2^40 - 2^0
-
-	t1 = t0.square()             // 2^41 - 2^1
-	t0 = t1.square()             // 2^42 - 2^2
-	for i := 2; i < 10; i += 2 { // 2^50 - 2^10
-		t1 = t0.square()
-		t0 = t1.square()
-	}
-	a2_50_0 := t0.mult(a2_10_0) // 2^50 - 2^0
-
-	t0 = a2_50_0.square()        // 2^51 - 2^1
-	t1 = t0.square()             // 2^52 - 2^2
-	for i := 2; i < 50; i += 2 { // 2^100 - 2^50
-		t0 = t1.square()
-		t1 = t0.square()
-	}
-	a2_100_0 := t1.mult(a2_50_0) // 2^100 - 2^0
-
-	t1 = a2_100_0.square()        // 2^101 - 2^1
-	t0 = t1.square()              // 2^102 - 2^2
-	for i := 2; i < 100; i += 2 { // 2^200 - 2^100
-		t1 = t0.square()
-		t0 = t1.square()
-	}
-	t1 = t0.mult(a2_100_0) // 2^200 - 2^0
-
-	t0 = t1.square()             // 2^201 - 2^1
-	t1 = t0.square()             // 2^202 - 2^2
-	for i := 2; i < 50; i += 2 { // 2^250 - 2^50
-		t0 = t1.square()
-		t1 = t0.square()
-	}
-	t0 = t1.mult(a2_50_0) // 2^250 - 2^0
-
-	t1 = t0.square()        // 2^251 - 2^1
-	t0 = t1.square()        // 2^252 - 2^2
-	a2_252_1 := t0.mult(a2) // 2^252 - 2^1
-
-	t1 = t0.square() // 2^253 - 2^3
-	t0 = t1.mult(a2) // 2^253 - 6
-	t1 = t0.mult(a)  // 2^253 - 5
-	a2_252_1ρS := a2_252_1.mult(ρS)
-
-	if t1.equals(minus1) {
-		out = selectUnpacked(a2_252_1, a2_252_1ρS, 1)
-	} else {
-		out = selectUnpacked(a2_252_1, a2_252_1ρS, 0)
-	}
-
-	// Check the root
-	hasRoot = out.square().equals(a)
-	return
-}
-
-// Computes the reciprocal of an unpacked integer (in the prime field modulo p)
-func (a unpacked) recip() (out unpacked) {
-	a2 := a.square()      // 2
-	t1 := a2.square()     // 4
-	t0 := t1.square()     // 8
-	a9 := t0.mult(a)      // 9
-	a11 := a9.mult(a2)    // 11
-	t0 = a11.square()     // 22
-	a2_5_0 := t0.mult(a9) // 2^5 - 2^0 = 31
-
-	t0 = a2_5_0.square()       // 2^6 - 2^1
-	t1 = t0.square()           // 2^7 - 2^2
-	t0 = t1.square()           // 2^8 - 2^3
-	t1 = t0.square()           // 2^9 - 2^4
-	t0 = t1.square()           // 2^10 - 2^5
-	a2_10_0 := t0.mult(a2_5_0) // 2^10 - 2^0
-
-	t0 = a2_10_0.square()        // 2^11 - 2^1
-	t1 = t0.square()             // 2^12 - 2^2
-	for i := 2; i < 10; i += 2 { // 2^20 - 2^10
-		t0 = t1.square()
-		t1 = t0.square()
-	}
-	a2_20_0 := t1.mult(a2_10_0) // 2^20 - 2^0
-
-	t0 = a2_20_0.square()        // 2^21 - 2^1
-	t1 = t0.square()             // 2^22 - 2^2
-	for i := 2; i < 20; i += 2 { // 2^40 - 2^20
-		t0 = t1.square()
-		t1 = t0.square()
-	}
-	t0 = t1.mult(a2_20_0) // 2^40 - 2^0
-
-	t1 = t0.square()             // 2^41 - 2^1
-	t0 = t1.square()             // 2^42 - 2^2
-	for i := 2; i < 10; i += 2 { // 2^50 - 2^10
-		t1 = t0.square()
-		t0 = t1.square()
-	}
-	a2_50_0 := t0.mult(a2_10_0) // 2^50 - 2^0
-
-	t0 = a2_50_0.square()        // 2^51 - 2^1
-	t1 = t0.square()             // 2^52 - 2^2
-	for i := 2; i < 50; i += 2 { // 2^100 - 2^50
-		t0 = t1.square()
-		t1 = t0.square()
-	}
-	a2_100_0 := t1.mult(a2_50_0) // 2^100 - 2^0
-
-	t1 = a2_100_0.square()        // 2^101 - 2^1
-	t0 = t1.square()              // 2^102 - 2^2
-	for i := 2; i < 100; i += 2 { // 2^200 - 2^100
-		t1 = t0.square()
-		t0 = t1.square()
-	}
-	t1 = t0.mult(a2_100_0) // 2^200 - 2^0
-
-	t0 = t1.square()             // 2^201 - 2^1
-	t1 = t0.square()             // 2^202 - 2^2
-	for i := 2; i < 50; i += 2 { // 2^250 - 2^50
-		t0 = t1.square()
-		t1 = t0.square()
-	}
-	t0 = t1.mult(a2_50_0) // 2^250 - 2^0
-
-	t1 = t0.square() // 2^251 - 2^1
-	t0 = t1.square() // 2^252 - 2^2
-	t1 = t0.square() // 2^253 - 2^3
-	t0 = t1.square() // 2^254 - 2^4
-	t1 = t0.square() // 2^255 - 2^5
-
-	return t1.mult(a11) // 2^255 - 21
-}
-
 // Checks if the X and Y coordinates of a work structure represent a valid point of the curve
 //
-// Also fills out the T coordinate.
+// The coordinates are the internal (Ed25519) ones. Also fills out the T
+// coordinate.
 func (w *Point) checkLoadXY() (ok bool) {
 	/* Check validity */
 	x2 := w.X.square()
 	y2 := w.Y.square()
-	ax2 := x2.multInt(486664)
-	dx2 := x2.multInt(486660)
-	ax2y2 := ax2.add(y2)
-	dx2y2 := dx2.mult(y2)
-	r := ax2y2.sub(one.add(dx2y2)).squeeze()
 
-	if ok = r.isZero(); ok {
+	// -X^2 + Y^2 = 1 + d*X^2*Y^2 with d = -121665/121666, both sides
+	// are multiplied with 121666
+	l := y2.sub(x2).sub(feOne).multInt(121666)
+	r := x2.mult(y2).multInt(121665)
+
+	if ok = l.add(r).squeeze().isZero(); ok {
 		w.T = w.X.mult(w.Y)
 	}
 	return
@@ -546,77 +386,71 @@ func (w *Point) checkLoadXY() (ok bool) {
 
 // LoadXYEd25519 loads a point of the Ed25519 curve with given
 // coordinates into its unpacked representation
+//
+// ok is false if a coordinate isn't in the range [0,p-1] or if they
+// don't describe a point of the curve, see DecodeXYEd25519 for a
+// decoder returning the reason.
 func LoadXYEd25519(x, y *Int256) (out *Point, ok bool) {
-	var tmp unpacked
-	for i := 0; i < 32; i++ {
-		tmp[i] = uint32(x[i])
-		out.Y[i] = uint32(y[i])
-		if i == 0 {
-			out.Z[i] = 1
-		}
+	out, err := loadXYEd25519(x, y)
+	return out, err == nil
+}
+
+func loadXYEd25519(x, y *Int256) (*Point, error) {
+	if !isCanonicalFe(x) || !isCanonicalFe(y) {
+		return nil, ErrNonCanonical
 	}
 
-	out.X = tmp.mult(ed25519ToLegacy)
-	if out.checkLoadXY() {
-		return out, true
+	out := &Point{X: feFromInt256(x), Y: feFromInt256(y), Z: feOne}
+	if !out.checkLoadXY() {
+		return nil, ErrNotOnCurve
 	}
-	return nil, false
+	return out, nil
 }
 
 // LoadXYLegacy loads a point of the legacy curve with given coordinates
 // into its unpacked representation
+//
+// ok is false if a coordinate isn't in the range [0,p-1] or if they
+// don't describe a point of the curve, see DecodeXYLegacy for a decoder
+// returning the reason.
 func LoadXYLegacy(x, y *Int256) (out *Point, ok bool) {
-	var tmp unpacked
+	out, err := loadXYLegacy(x, y)
+	return out, err == nil
+}
 
-	for i := 0; i < 32; i++ {
-		tmp[i] = uint32(x[i])
-		out.Y[i] = uint32(y[i])
-		if i == 0 {
-			out.Z[i] = 1
-		}
+func loadXYLegacy(x, y *Int256) (*Point, error) {
+	if !isCanonicalFe(x) || !isCanonicalFe(y) {
+		return nil, ErrNonCanonical
 	}
 
-	out.X = tmp.mult(legacyToEd25519)
-
-	if out.checkLoadXY() {
-		return out, true
+	tmp := feFromInt256(x)
+	out := &Point{X: tmp.mult(legacyToEd25519), Y: feFromInt256(y), Z: feOne}
+	if !out.checkLoadXY() {
+		return nil, ErrNotOnCurve
 	}
-	return nil, false
+	return out, nil
 }
 
 // StoreXYEd25519 stores the x and y coordinates of a point of the Ed25519 curve
 func (w *Point) StoreXYEd25519() (x, y *Int256) {
-	x, y = &Int256{}, &Int256{}
-
-	Z := w.Z.recip()
-	tmp := Z.mult(w.X)
-	X := tmp.mult(legacyToEd25519).freeze()
-	for i := 0; i < 32; i++ {
-		x[i] = uint8(X[i])
-	}
+	return w.storeXYEd25519(w.Z.recip())
+}
 
-	Y := Z.mult(w.Y).freeze()
-	for i := 0; i < 32; i++ {
-		y[i] = uint8(Y[i])
-	}
-	return
+// Stores the x and y coordinates of a point of the Ed25519 curve, given
+// the reciprocal of the Z coordinate
+func (w *Point) storeXYEd25519(zInv fe) (x, y *Int256) {
+	return zInv.mult(w.X).toInt256(), zInv.mult(w.Y).toInt256()
 }
 
 // StoreXYLegacy stores the x and y coordinates of a point of the legacy curve
 func (w *Point) StoreXYLegacy() (x, y *Int256) {
-	x, y = &Int256{}, &Int256{}
-
-	Z := w.Z.recip()
-	X := Z.mult(w.X).mult(ed25519ToLegacy).freeze()
-	for i := 0; i < 32; i++ {
-		x[i] = uint8(X[i])
-	}
+	return w.storeXYLegacy(w.Z.recip())
+}
 
-	Y := Z.mult(w.Y).freeze()
-	for i := 0; i < 32; i++ {
-		y[i] = uint8(Y[i])
-	}
-	return
+// Stores the x and y coordinates of a point of the legacy curve, given
+// the reciprocal of the Z coordinate
+func (w *Point) storeXYLegacy(zInv fe) (x, y *Int256) {
+	return zInv.mult(w.X).mult(ed25519ToLegacy).toInt256(), zInv.mult(w.Y).toInt256()
 }
 
 // LoadPackedEd25519 loads a packed point of the Ed25519 curve into its
@@ -625,34 +459,43 @@ func (w *Point) StoreXYLegacy() (x, y *Int256) {
 // The packed format is different from the legacy one: the legacy format
 // contains that X coordinate and the parity of the Y coordinate,
 // Ed25519 uses the Y coordinate and the parity of the X coordinate.
+//
+// The decoding is lenient: the Y coordinate is taken modulo p and the
+// sign of X = 0 is ignored, which matches the rules of ZIP-215. nil is
+// returned if the input doesn't encode a point of the curve, see
+// DecodePackedEd25519 for a strict decoder returning the reason.
 func (in *Int256) LoadPackedEd25519() *Point {
-	a := unpacked{486664}
+	out, _ := loadPackedEd25519(in)
+	return out
+}
 
-	out := &Point{Z: unpacked{1}}
-	for i := 0; i < 32; i++ {
-		out.Y[i] = uint32(in[i])
-	}
-	out.Y[31] &= 0x7f
+// Loads a packed point of the Ed25519 curve, the Y coordinate is taken
+// modulo p
+func loadPackedEd25519(in *Int256) (*Point, error) {
+	out := &Point{Y: feFromInt256(in), Z: feOne}
 
+	// X^2 = (Y^2 - 1) / (d*Y^2 + 1) with d = -121665/121666, both
+	// numerator and denominator are multiplied with 121666
 	Y2 := out.Y.square()
-	dY2 := Y2.multInt(486660)
-	X2 := one.sub(Y2).mult(a.sub(dY2).recip())
+	Y2m1 := Y2.sub(feOne).multInt(121666)
+	dY2p1 := feOne.multInt(121666).sub(Y2.multInt(121665))
+	X2 := Y2m1.mult(dY2p1.recip())
 
 	X, ok := X2.sqrt()
 	if !ok {
-		return nil
+		return nil, ErrNotOnCurve
 	}
 
-	parity := X.mult(legacyToEd25519).parity()
+	parity := X.parity()
 
 	// No squeeze is necessary after subtractions from zero if the
 	// subtrahend is squeezed
-	Xt := zero.sub(X)
+	Xt := feZero.sub(X)
 
-	out.X = selectUnpacked(X, Xt, uint32((in[31]>>7))^parity)
+	out.X = selectFe(X, Xt, uint32((in[31]>>7))^parity)
 	out.T = out.X.mult(out.Y)
 
-	return out
+	return out, nil
 }
 
 // LoadPackedLegacy loads a packed point of the legacy curve into its
@@ -661,33 +504,41 @@ func (in *Int256) LoadPackedEd25519() *Point {
 // The packed format is different from the Ed25519 one: the legacy
 // format contains that X coordinate and the parity of the Y coordinate,
 // Ed25519 uses the Y coordinate and the parity of the X coordinate.
+//
+// The decoding is lenient: the X coordinate is taken modulo p and the
+// sign of Y = 0 is ignored. nil is returned if the input doesn't encode
+// a point of the curve, see DecodePackedLegacy for a strict decoder
+// returning the reason.
 func (in *Int256) LoadPackedLegacy() *Point {
-	out := &Point{Z: unpacked{1}}
-	var xLegacy unpacked
-	for i := 0; i < 32; i++ {
-		xLegacy[i] = uint32(in[i])
-	}
-	xLegacy[31] &= 0x7f
+	out, _ := loadPackedLegacy(in)
+	return out
+}
+
+// Loads a packed point of the legacy curve, the X coordinate is taken
+// modulo p
+func loadPackedLegacy(in *Int256) (*Point, error) {
+	out := &Point{Z: feOne}
+	xLegacy := feFromInt256(in)
 
 	X2 := xLegacy.square()
 	aX2 := X2.multInt(486664)
 	dX2 := X2.multInt(486660)
-	Y2 := one.sub(aX2).mult(one.sub(dX2).recip())
+	Y2 := feOne.sub(aX2).mult(feOne.sub(dX2).recip())
 
 	Y, ok := Y2.sqrt()
 	if !ok {
-		return nil
+		return nil, ErrNotOnCurve
 	}
 
 	// No squeeze is necessary after subtractions from zero if the
 	// subtrahend is squeezed
-	Yt := zero.sub(Y)
+	Yt := feZero.sub(Y)
 
-	out.Y = selectUnpacked(Y, Yt, uint32(in[31]>>7)^Y.parity())
+	out.Y = selectFe(Y, Yt, uint32(in[31]>>7)^Y.parity())
 	out.X = xLegacy.mult(legacyToEd25519)
 	out.T = out.X.mult(out.Y)
 
-	return out
+	return out, nil
 }
 
 // StorePackedEd25519 stores a point of the Ed25519 curve into its
@@ -702,6 +553,24 @@ func (w *Point) StorePackedEd25519() *Int256 {
 	return out
 }
 
+// StorePackedEd25519Batch stores many points of the Ed25519 curve into
+// their packed representations
+//
+// This gives the same results as calling StorePackedEd25519 for every
+// point, but is much faster for large slices, as only a single
+// inversion is needed for all points.
+func StorePackedEd25519Batch(points []*Point) []*Int256 {
+	zInv := recipZ(points)
+
+	out := make([]*Int256, len(points))
+	for i, p := range points {
+		x, y := p.storeXYEd25519(zInv[i])
+		y[31] |= (x[0] << 7)
+		out[i] = y
+	}
+	return out
+}
+
 // StorePackedLegacy stores a point of the legacy curve into its packed
 // representation
 //
@@ -714,6 +583,33 @@ func (w *Point) StorePackedLegacy() *Int256 {
 	return out
 }
 
+// StorePackedLegacyBatch stores many points of the legacy curve into
+// their packed representations
+//
+// This gives the same results as calling StorePackedLegacy for every
+// point, but is much faster for large slices, as only a single
+// inversion is needed for all points.
+func StorePackedLegacyBatch(points []*Point) []*Int256 {
+	zInv := recipZ(points)
+
+	out := make([]*Int256, len(points))
+	for i, p := range points {
+		x, y := p.storeXYLegacy(zInv[i])
+		x[31] |= (y[0] << 7)
+		out[i] = x
+	}
+	return out
+}
+
+// Computes the reciprocals of the Z coordinates of many points
+func recipZ(points []*Point) []fe {
+	z := make([]fe, len(points))
+	for i, p := range points {
+		z[i] = p.Z
+	}
+	return feRecipBatch(z)
+}
+
 // IsIdentity checks if a point is the identity element of the Elliptic
 // Curve group
 func (w *Point) IsIdentity() bool {
@@ -721,35 +617,151 @@ func (w *Point) IsIdentity() bool {
 	return w.X.isZero() && yz.isZero()
 }
 
+// Equal checks if two points of the Elliptic Curve are equal
+//
+// As the representation of a point isn't unique, the projective
+// coordinates are cross-multiplied (X1*Z2 = X2*Z1 and Y1*Z2 = Y2*Z1)
+// instead of comparing them directly. This takes constant time and is
+// much cheaper than comparing the packed encodings, which need an
+// inversion each.
+func (w *Point) Equal(o *Point) bool {
+	x1 := w.X.mult(o.Z).canonical()
+	x2 := o.X.mult(w.Z).canonical()
+	y1 := w.Y.mult(o.Z).canonical()
+	y2 := o.Y.mult(w.Z).canonical()
+
+	return x1.equalsBit(x2)&y1.equalsBit(y2) == 1
+}
+
+// MultByCofactor multiplies a point with the cofactor 8 of the curve
+//
+// For points on the curve, the result is in the prime order subgroup
+// generated by the base point. Points that aren't on the curve (which
+// can only be created by filling in the coordinates directly) give no
+// such guarantee.
+func (w *Point) MultByCofactor() *Point {
+	return w.Double().Double().Double()
+}
+
+// IsSmallOrder checks if a point is one of the eight points of small
+// order, i.e. if multiplying it with the cofactor gives the identity
+// element
+//
+// Such points must be rejected when received from a peer, as the result
+// of a key exchange with them doesn't depend on the secret key.
+func (w *Point) IsSmallOrder() bool {
+	return w.MultByCofactor().IsIdentity()
+}
+
+// IsTorsionFree checks if a point is in the prime order subgroup
+// generated by the base point, i.e. if multiplying it with the group
+// order gives the identity element
+//
+// Points of mixed order (the sum of a point of the subgroup and a point
+// of small order) are rejected by this check, but not by IsSmallOrder.
+// This takes as long as a scalar multiplication.
+func (w *Point) IsTorsionFree() bool {
+	return w.ScalarMult(&gfOrder).IsIdentity()
+}
+
+// Ed25519 encodings of the points of small order: the identity element,
+// the point of order 2, two points of order 4 and four points of
+// order 8
+var smallOrderEncodings = [8]Int256{
+	{0x01},
+	{
+		0xec, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
+	},
+	{0x00},
+	{31: 0x80},
+	{
+		0x26, 0xe8, 0x95, 0x8f, 0xc2, 0xb2, 0x27, 0xb0,
+		0x45, 0xc3, 0xf4, 0x89, 0xf2, 0xef, 0x98, 0xf0,
+		0xd5, 0xdf, 0xac, 0x05, 0xd3, 0xc6, 0x33, 0x39,
+		0xb1, 0x38, 0x02, 0x88, 0x6d, 0x53, 0xfc, 0x05,
+	},
+	{
+		0x26, 0xe8, 0x95, 0x8f, 0xc2, 0xb2, 0x27, 0xb0,
+		0x45, 0xc3, 0xf4, 0x89, 0xf2, 0xef, 0x98, 0xf0,
+		0xd5, 0xdf, 0xac, 0x05, 0xd3, 0xc6, 0x33, 0x39,
+		0xb1, 0x38, 0x02, 0x88, 0x6d, 0x53, 0xfc, 0x85,
+	},
+	{
+		0xc7, 0x17, 0x6a, 0x70, 0x3d, 0x4d, 0xd8, 0x4f,
+		0xba, 0x3c, 0x0b, 0x76, 0x0d, 0x10, 0x67, 0x0f,
+		0x2a, 0x20, 0x53, 0xfa, 0x2c, 0x39, 0xcc, 0xc6,
+		0x4e, 0xc7, 0xfd, 0x77, 0x92, 0xac, 0x03, 0x7a,
+	},
+	{
+		0xc7, 0x17, 0x6a, 0x70, 0x3d, 0x4d, 0xd8, 0x4f,
+		0xba, 0x3c, 0x0b, 0x76, 0x0d, 0x10, 0x67, 0x0f,
+		0x2a, 0x20, 0x53, 0xfa, 0x2c, 0x39, 0xcc, 0xc6,
+		0x4e, 0xc7, 0xfd, 0x77, 0x92, 0xac, 0x03, 0xfa,
+	},
+}
+
+// SmallOrderPoints returns copies of the eight points of small order,
+// which are useful to test the handling of malicious inputs
+//
+// The first point is the identity element, followed by the point of
+// order 2, the two points of order 4 and the four points of order 8.
+func SmallOrderPoints() []*Point {
+	out := make([]*Point, len(smallOrderEncodings))
+	for i := range smallOrderEncodings {
+		out[i] = smallOrderEncodings[i].LoadPackedEd25519()
+	}
+	return out
+}
+
+// Set sets p to the value of q and returns p
+func (p *Point) Set(q *Point) *Point {
+	*p = *q
+	return p
+}
+
 // Negate negates a point of the Elliptic Curve
 func (w *Point) Negate() *Point {
-	out := &Point{}
-	for i := 0; i < 32; i++ {
-		out.Y[i] = w.Y[i]
-		out.Z[i] = w.Z[i]
-	}
+	return new(Point).SetNegate(w)
+}
 
+// SetNegate sets p to the negation of q and returns p
+//
+// Like the other Set methods, it doesn't allocate and p may be the same
+// point as q.
+func (p *Point) SetNegate(q *Point) *Point {
 	// No squeeze is necessary after subtractions from zero if the
 	// subtrahend is squeezed
-	out.X = zero.sub(w.X)
-	out.T = zero.sub(w.T)
-	return out
+	*p = Point{
+		X: feZero.sub(q.X),
+		Y: q.Y,
+		Z: q.Z,
+		T: feZero.sub(q.T),
+	}
+	return p
 }
 
 // Double doubles a point of the Elliptic Curve
 //
 // w.Double() is equivalent to w.add(w), but faster.
 func (w *Point) Double() *Point {
-	A := w.X.square()
+	return new(Point).SetDouble(w)
+}
 
-	B := w.Y.square()
+// SetDouble sets p to 2*q and returns p
+func (p *Point) SetDouble(q *Point) *Point {
+	A := q.X.square()
 
-	t0 := w.Z.square()
+	B := q.Y.square()
+
+	t0 := q.Z.square()
 	C := t0.multInt(2)
 
-	D := zero.sub(A)
+	D := feZero.sub(A)
 
-	t0 = w.X.add(w.Y)
+	t0 = q.X.add(q.Y)
 	t1 := t0.square()
 	t0 = t1.sub(A)
 	E := t0.sub(B)
@@ -758,48 +770,73 @@ func (w *Point) Double() *Point {
 	F := G.sub(C)
 	H := D.sub(B)
 
-	return &Point{
+	*p = Point{
 		X: E.mult(F),
 		Y: G.mult(H),
 		T: E.mult(H),
 		Z: F.mult(G),
 	}
+	return p
 }
 
 // Add adds two points of the Elliptic Curve
 func (w *Point) Add(o *Point) *Point {
-	t0 := w.Y.sub(w.X)
+	return new(Point).SetAdd(w, o)
+}
+
+// SetAdd sets p to the sum a+b and returns p
+func (p *Point) SetAdd(a, b *Point) *Point {
+	t0 := a.Y.sub(a.X)
 	t1 := t0.multInt(60833)
-	t0 = o.Y.sub(o.X)
+	t0 = b.Y.sub(b.X)
 	A := t0.mult(t1)
 
-	t0 = w.Y.add(w.X)
+	t0 = a.Y.add(a.X)
 	t1 = t0.multInt(60833)
-	t0 = o.Y.add(o.X)
+	t0 = b.Y.add(b.X)
 	B := t0.mult(t1)
 
-	t0 = o.T.multInt(121665)
-	C := w.T.mult(t0)
+	t0 = b.T.multInt(121665)
+	C := a.T.mult(t0)
 
-	t0 = o.Z.multInt(2 * 60833)
-	D := w.Z.mult(t0)
+	t0 = b.Z.multInt(2 * 60833)
+	D := a.Z.mult(t0)
 
 	E := B.sub(A)
 	F := D.add(C)
 	G := D.sub(C)
 	H := B.add(A)
 
-	return &Point{
+	*p = Point{
 		X: E.mult(F),
 		Y: G.mult(H),
 		T: E.mult(H),
 		Z: F.mult(G),
 	}
+	return p
 }
 
 // Sub subtracts two points of the Elliptic Curve
 func (w *Point) Sub(o *Point) *Point {
-	return w.Add(o.Negate())
+	return new(Point).SetSub(w, o)
+}
+
+// SetSub sets p to the difference a-b and returns p
+func (p *Point) SetSub(a, b *Point) *Point {
+	var nb Point
+	nb.SetNegate(b)
+	return p.SetAdd(a, &nb)
+}
+
+// Computes the multiples w, 2w, ..., 8w of a point in cached form
+func (w *Point) multiples() (table [8]CachedPoint) {
+	cur := *w
+	table[0].SetPoint(w)
+	for j := 1; j < 8; j++ {
+		cur.SetAddCached(&cur, &table[0])
+		table[j].SetPoint(&cur)
+	}
+	return
 }
 
 // ScalarMultBits does a scalar multiplication of a point of the
@@ -809,21 +846,50 @@ func (w *Point) Sub(o *Point) *Point {
 // 256 bits of the scalar are used. The bit length should always be a
 // constant and not computed at runtime to ensure that no timing attacks
 // are possible.
+//
+// The scalar is processed in signed radix 16 digits, using a table of
+// the multiples w, 2w, ..., 8w which is searched in constant time.
 func (w *Point) ScalarMultBits(n *Int256, bits int) *Point {
+	return new(Point).SetScalarMultBits(w, n, bits)
+}
+
+// SetScalarMultBits sets p to the product n*q, using only the lowest
+// bits of n, and returns p
+//
+// See ScalarMultBits for details.
+func (p *Point) SetScalarMultBits(q *Point, n *Int256, bits int) *Point {
 	if bits > 256 {
 		bits = 256
 	}
+	if bits <= 0 {
+		*p = pointIdentity
+		return p
+	}
+
+	// Clear the unused bits
+	var m Int256
+	copy(m[:], n[:(bits+7)/8])
+	if r := uint(bits) & 7; r != 0 {
+		m[(bits-1)/8] &= 1<<r - 1
+	}
+
+	table := q.multiples()
+
+	var e [65]int8
+	digits := (bits + 3) / 4
+	recodeRadix16(&m, e[:digits+1])
 
 	cur := pointIdentity
-	for pos := bits - 1; pos >= 0; pos-- {
-		b := n[pos/8] >> (uint(pos) & 7)
-		b &= 1
+	for i := digits; i >= 0; i-- {
+		if i < digits {
+			cur.SetDouble(&cur).SetDouble(&cur).SetDouble(&cur).SetDouble(&cur)
+		}
 
-		q2 := cur.Double()
-		q2p := q2.Add(w)
-		cur = selectPoint(q2, q2p, uint32(b))
+		t := lookupSigned(&table, e[i])
+		cur.SetAddCached(&cur, &t)
 	}
-	return &cur
+	*p = cur
+	return p
 }
 
 // ScalarMult does a scalar multiplication of a point of the Elliptic
@@ -831,3 +897,198 @@ func (w *Point) ScalarMultBits(n *Int256, bits int) *Point {
 func (w *Point) ScalarMult(n *Int256) *Point {
 	return w.ScalarMultBits(n, 256)
 }
+
+// SetScalarMult sets p to the product n*q and returns p
+func (p *Point) SetScalarMult(q *Point, n *Int256) *Point {
+	return p.SetScalarMultBits(q, n, 256)
+}
+
+// Below this number of points, MultiScalarMultVartime uses Straus'
+// method instead of Pippenger's bucket method (the crossover measured by
+// BenchmarkMultiScalarMultVartime)
+const pippengerThreshold = 256
+
+// MultiScalarMult computes the sum of scalars[i]*points[i]
+//
+// This is considerably faster than separate scalar multiplications, as
+// the doublings are shared between all points (Straus' method). The time
+// needed doesn't depend on the scalars or points, only on their number.
+//
+// nil is returned if the number of scalars and points don't match.
+func MultiScalarMult(scalars []*Int256, points []*Point) *Point {
+	if len(scalars) != len(points) {
+		return nil
+	}
+
+	tables := make([][8]CachedPoint, len(points))
+	digits := make([][65]int8, len(points))
+	for i := range points {
+		tables[i] = points[i].multiples()
+		recodeRadix16(scalars[i], digits[i][:])
+	}
+
+	cur := pointIdentity
+	for j := 64; j >= 0; j-- {
+		if j < 64 {
+			cur.SetDouble(&cur).SetDouble(&cur).SetDouble(&cur).SetDouble(&cur)
+		}
+		for i := range tables {
+			q := lookupSigned(&tables[i], digits[i][j])
+			cur.SetAddCached(&cur, &q)
+		}
+	}
+	return &cur
+}
+
+// MultiScalarMultVartime computes the sum of scalars[i]*points[i]
+//
+// For small inputs, Straus' method with sliding windows is used, while
+// large inputs are handled by Pippenger's bucket method.
+//
+// The time needed depends on the scalars and points, so it MUST NOT be
+// used with secret inputs. nil is returned if the number of scalars and
+// points don't match.
+func MultiScalarMultVartime(scalars []*Int256, points []*Point) *Point {
+	if len(scalars) != len(points) {
+		return nil
+	}
+
+	if len(points) < pippengerThreshold {
+		return strausVartime(scalars, points)
+	}
+
+	switch {
+	case len(points) < 512:
+		return pippenger(scalars, points, 7)
+	case len(points) < 2048:
+		return pippenger(scalars, points, 8)
+	default:
+		return pippenger(scalars, points, 9)
+	}
+}
+
+// Straus' method using non-adjacent forms and tables of odd multiples
+func strausVartime(scalars []*Int256, points []*Point) *Point {
+	tables := make([][8]CachedPoint, len(points))
+	nafs := make([][257]int8, len(points))
+	for i := range points {
+		oddMultiples(points[i], tables[i][:])
+		nafs[i] = nonAdjacentForm(scalars[i], 15)
+	}
+
+	cur := pointIdentity
+	for j := 256; j >= 0; j-- {
+		cur.SetDouble(&cur)
+		for i := range tables {
+			addOddMultiple(&cur, tables[i][:], nafs[i][j])
+		}
+	}
+	return &cur
+}
+
+// Recodes n into signed radix 2^w digits
+//
+// The result satisfies n = sum(e[i] * 2^(w*i)), every digit but the last
+// one is in [-2^(w-1), 2^(w-1)-1].
+func recodeSignedRadix(n *Int256, w uint) []int32 {
+	digits := (256+int(w)-1)/int(w) + 1
+	e := make([]int32, digits)
+
+	carry := int32(0)
+	for i := 0; i < digits-1; i++ {
+		var d int32
+		for k := uint(0); k < w; k++ {
+			pos := uint(i)*w + k
+			if pos < 256 {
+				d |= int32(n[pos/8]>>(pos&7)&1) << k
+			}
+		}
+
+		d += carry
+		carry = (d + 1<<(w-1)) >> w
+		e[i] = d - carry<<w
+	}
+	e[digits-1] = carry
+	return e
+}
+
+// Pippenger's bucket method with signed digits of w bits
+//
+// All additions use cached points, the buckets are allocated once and
+// reused for every window.
+func pippenger(scalars []*Int256, points []*Point, w uint) *Point {
+	digits := make([][]int32, len(scalars))
+	cached := make([]CachedPoint, len(points))
+	for i := range scalars {
+		digits[i] = recodeSignedRadix(scalars[i], w)
+		cached[i].SetPoint(points[i])
+	}
+
+	buckets := make([]Point, 1<<(w-1))
+	filled := make([]bool, len(buckets))
+
+	var c CachedPoint
+	cur := pointIdentity
+	for j := len(digits[0]) - 1; j >= 0; j-- {
+		if j < len(digits[0])-1 {
+			for k := uint(0); k < w; k++ {
+				cur.SetDouble(&cur)
+			}
+		}
+
+		for k := range filled {
+			filled[k] = false
+		}
+		for i := range points {
+			d := digits[i][j]
+			if d == 0 {
+				continue
+			}
+
+			k := abs32(d) - 1
+			switch {
+			case !filled[k] && d > 0:
+				buckets[k] = *points[i]
+			case !filled[k]:
+				buckets[k].SetNegate(points[i])
+			case d > 0:
+				buckets[k].SetAddCached(&buckets[k], &cached[i])
+			default:
+				buckets[k].SetSubCached(&buckets[k], &cached[i])
+			}
+			filled[k] = true
+		}
+
+		// sum of (k+1)*buckets[k], as the sum of the running sums of the
+		// buckets from the highest one down
+		var running, sum Point
+		started := false
+		for k := len(buckets) - 1; k >= 0; k-- {
+			switch {
+			case filled[k] && started:
+				c.SetPoint(&buckets[k])
+				running.SetAddCached(&running, &c)
+			case filled[k]:
+				running, sum = buckets[k], pointIdentity
+				started = true
+			case !started:
+				continue
+			}
+			c.SetPoint(&running)
+			sum.SetAddCached(&sum, &c)
+		}
+		if started {
+			c.SetPoint(&sum)
+			cur.SetAddCached(&cur, &c)
+		}
+	}
+	return &cur
+}
+
+// Returns the absolute value of d
+func abs32(d int32) int32 {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"math/big"
+	"math/rand"
 	"testing"
 )
 
@@ -19,8 +21,11 @@ func bitIdentical(a, b unpacked) bool {
 	return true
 }
 
-func bitIdenticalWork(a, b *Point) bool {
-	return bitIdentical(a.X, b.X) && bitIdentical(a.Y, b.Y) && bitIdentical(a.Z, b.Z) && bitIdentical(a.T, b.T)
+// equalWork checks if the coordinates of two points have the same values,
+// independent of the field representation
+func equalWork(a, b *Point) bool {
+	return *a.X.toInt256() == *b.X.toInt256() && *a.Y.toInt256() == *b.Y.toInt256() &&
+		*a.Z.toInt256() == *b.Z.toInt256() && *a.T.toInt256() == *b.T.toInt256()
 }
 
 func loadUnpacked(fname string) unpacked {
@@ -70,14 +75,19 @@ func loadPoint(fname string) *Point {
 		panic("incomplete data")
 	}
 
-	p := &Point{}
+	var x, y, z, t unpacked
 	for i := 0; i < 32; i++ {
-		p.X[i] = binary.LittleEndian.Uint32(data[(0+i)*4:])
-		p.Y[i] = binary.LittleEndian.Uint32(data[(32+i)*4:])
-		p.Z[i] = binary.LittleEndian.Uint32(data[(64+i)*4:])
-		p.T[i] = binary.LittleEndian.Uint32(data[(96+i)*4:])
+		x[i] = binary.LittleEndian.Uint32(data[(0+i)*4:])
+		y[i] = binary.LittleEndian.Uint32(data[(32+i)*4:])
+		z[i] = binary.LittleEndian.Uint32(data[(64+i)*4:])
+		t[i] = binary.LittleEndian.Uint32(data[(96+i)*4:])
+	}
+	return &Point{
+		X: feFromUnpacked(x),
+		Y: feFromUnpacked(y),
+		Z: feFromUnpacked(z),
+		T: feFromUnpacked(t),
 	}
-	return p
 }
 
 func TestPointDouble(t *testing.T) {
@@ -85,7 +95,7 @@ func TestPointDouble(t *testing.T) {
 
 	actual := PointBaseLegacy().Double()
 
-	if !bitIdenticalWork(expected, actual) {
+	if !equalWork(expected, actual) {
 		t.Errorf(errmsg, expected, actual)
 	}
 }
@@ -95,7 +105,7 @@ func TestPointAdd(t *testing.T) {
 
 	actual := pointIdentity.Add(PointBaseLegacy())
 
-	if !bitIdenticalWork(expected, actual) {
+	if !equalWork(expected, actual) {
 		t.Errorf(errmsg, expected, actual)
 	}
 }
@@ -181,10 +191,10 @@ func TestIsZero(t *testing.T) {
 func TestSelectPoint(t *testing.T) {
 	a, b := pointBaseEd25519, pointIdentity
 
-	if w := selectPoint(&a, &b, 0); !bitIdenticalWork(&w, &a) {
+	if w := selectPoint(&a, &b, 0); !equalWork(&w, &a) {
 		t.Error("selectPoint(a,b,0) did not return a")
 	}
-	if w := selectPoint(&a, &b, 1); !bitIdenticalWork(&w, &b) {
+	if w := selectPoint(&a, &b, 1); !equalWork(&w, &b) {
 		t.Error("selectPoint(a,b,1) did not return b")
 	}
 }
@@ -210,7 +220,7 @@ func TestKeyLoading(t *testing.T) {
 			actual := k1.LoadPackedLegacy()
 			expected := loadPoint(fmt.Sprintf("testdata/cases/ecc_key_unpacked_%d", i))
 
-			if !bitIdenticalWork(actual, expected) {
+			if !equalWork(actual, expected) {
 				t.Errorf(errmsg, expected, actual)
 			}
 		})
@@ -321,3 +331,518 @@ got      hex: %04[2]x
 expected dec: %[1]v
 got      dec: %[2]v
 `
+
+// scalarMultBitsReference is the plain double-and-add scalar
+// multiplication, used as reference for the windowed implementation
+func scalarMultBitsReference(w *Point, n *Int256, bits int) *Point {
+	cur := pointIdentity
+	for pos := bits - 1; pos >= 0; pos-- {
+		cur = *cur.Double()
+		if (n[pos/8]>>(uint(pos)&7))&1 == 1 {
+			cur = *cur.Add(w)
+		}
+	}
+	return &cur
+}
+
+func TestScalarMultBits(t *testing.T) {
+	w := PointBaseLegacy().ScalarMult(loadInt256File("testdata/cases/ecc_key_0"))
+
+	for i, n := range testScalars() {
+		for _, bits := range []int{0, 1, 3, 4, 7, 8, 13, 64, 128, 251, 255, 256} {
+			t.Run(fmt.Sprintf("%d_%d", i, bits), func(t *testing.T) {
+				expected := scalarMultBitsReference(w, n, bits).StorePackedLegacy()
+				actual := w.ScalarMultBits(n, bits).StorePackedLegacy()
+
+				if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+					t.Errorf(errmsg, expected, actual)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkScalarMult(b *testing.B) {
+	w := PointBaseLegacy().ScalarMult(loadInt256File("testdata/cases/ecc_key_0"))
+	n := loadInt256File("testdata/cases/ecc_key_1")
+
+	for i := 0; i < b.N; i++ {
+		w.ScalarMult(n)
+	}
+}
+
+// multiScalarMultReference sums up separate scalar multiplications
+func multiScalarMultReference(scalars []*Int256, points []*Point) *Point {
+	sum := &Point{}
+	*sum = pointIdentity
+	for i := range points {
+		sum = sum.Add(points[i].ScalarMult(scalars[i]))
+	}
+	return sum
+}
+
+func testMultiScalarMultInput(n int) (scalars []*Int256, points []*Point) {
+	all := testScalars()
+	for i := 0; i < n; i++ {
+		scalars = append(scalars, all[i%len(all)])
+		points = append(points, ScalarMultBaseLegacy(all[(i+3)%len(all)]))
+	}
+	return
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 5} {
+		scalars, points := testMultiScalarMultInput(n)
+		expected := multiScalarMultReference(scalars, points).StorePackedLegacy()
+
+		t.Run(fmt.Sprintf("ct_%d", n), func(t *testing.T) {
+			actual := MultiScalarMult(scalars, points).StorePackedLegacy()
+			if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+				t.Errorf(errmsg, expected, actual)
+			}
+		})
+
+		t.Run(fmt.Sprintf("vartime_%d", n), func(t *testing.T) {
+			actual := MultiScalarMultVartime(scalars, points).StorePackedLegacy()
+			if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+				t.Errorf(errmsg, expected, actual)
+			}
+		})
+
+		for _, w := range []uint{5, 6, 7, 8, 9} {
+			t.Run(fmt.Sprintf("pippenger_%d_%d", n, w), func(t *testing.T) {
+				if n == 0 {
+					t.Skip()
+				}
+				actual := pippenger(scalars, points, w).StorePackedLegacy()
+				if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+					t.Errorf(errmsg, expected, actual)
+				}
+			})
+		}
+	}
+
+	if MultiScalarMult(make([]*Int256, 2), nil) != nil {
+		t.Error("expected nil for mismatched input lengths")
+	}
+	if MultiScalarMultVartime(make([]*Int256, 2), nil) != nil {
+		t.Error("expected nil for mismatched input lengths")
+	}
+}
+
+// Returns n random scalars (reduced modulo the group order, like the
+// scalars of VerifyBatch) and points for benchmarks
+func benchMultiScalarMultInput(n int) (scalars []*Int256, points []*Point) {
+	rnd := rand.New(rand.NewSource(int64(n)))
+	for i := 0; i < n; i++ {
+		var s, k Int256
+		rnd.Read(s[:])
+		rnd.Read(k[:])
+		scalars = append(scalars, s.GfReduce())
+		points = append(points, ScalarMultBaseLegacy(&k))
+	}
+	return
+}
+
+func BenchmarkMultiScalarMultVartime(b *testing.B) {
+	for _, n := range []int{64, 128, 256, 384, 512, 1024, 2048} {
+		scalars, points := benchMultiScalarMultInput(n)
+
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiScalarMultVartime(scalars, points)
+			}
+		})
+		b.Run(fmt.Sprintf("straus_%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				strausVartime(scalars, points)
+			}
+		})
+		for _, w := range []uint{6, 7, 8, 9} {
+			b.Run(fmt.Sprintf("pippenger_%d_%d", n, w), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					pippenger(scalars, points, w)
+				}
+			})
+		}
+	}
+}
+
+func TestMultiScalarMultVartimePippenger(t *testing.T) {
+	scalars, points := testMultiScalarMultInput(pippengerThreshold)
+
+	expected := MultiScalarMult(scalars, points).StorePackedLegacy()
+	actual := MultiScalarMultVartime(scalars, points).StorePackedLegacy()
+	if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+		t.Errorf(errmsg, expected, actual)
+	}
+}
+
+func TestRecodeSignedRadix(t *testing.T) {
+	for i, n := range testScalars() {
+		for _, w := range []uint{5, 6, 7, 8} {
+			e := recodeSignedRadix(n, w)
+
+			// undo the recoding using math/big
+			actual := new(big.Int)
+			for j := len(e) - 1; j >= 0; j-- {
+				actual.Lsh(actual, w)
+				actual.Add(actual, big.NewInt(int64(e[j])))
+			}
+
+			expected := new(big.Int)
+			for j := 31; j >= 0; j-- {
+				expected.Lsh(expected, 8)
+				expected.Add(expected, big.NewInt(int64(n[j])))
+			}
+
+			if actual.Cmp(expected) != 0 {
+				t.Errorf("scalar %d, w=%d: recoding does not match", i, w)
+			}
+		}
+	}
+}
+
+func TestEd25519Coordinates(t *testing.T) {
+	// The Ed25519 generator point (RFC 8032, section 5.1)
+	expectedX := loadInt256Hex("1ad5258f602d56c9b2a7259560c72c695cdcd6fd31e2a4c0fe536ecdd3366921")
+	expectedY := loadInt256Hex("5866666666666666666666666666666666666666666666666666666666666666")
+
+	x, y := PointBaseEd25519().StoreXYEd25519()
+	if *x != *expectedX || *y != *expectedY {
+		t.Errorf(errmsg, expectedX, x)
+	}
+
+	p := expectedY.LoadPackedEd25519()
+	if p == nil {
+		t.Fatal("failed to load the packed generator point")
+	}
+	if x, _ := p.StoreXYEd25519(); *x != *expectedX {
+		t.Errorf(errmsg, expectedX, x)
+	}
+	if packed := PointBaseEd25519().StorePackedEd25519(); *packed != *expectedY {
+		t.Errorf(errmsg, expectedY, packed)
+	}
+}
+
+func TestPointSetMethods(t *testing.T) {
+	a := PointBaseLegacy().ScalarMult(loadInt256File("testdata/cases/ecc_key_0"))
+	b := PointBaseLegacy().ScalarMult(loadInt256File("testdata/cases/ecc_key_1"))
+	n := loadInt256File("testdata/cases/ecc_key_2")
+
+	tt := map[string]struct {
+		expected *Point
+		set      func(p *Point) *Point
+	}{
+		"add":           {a.Add(b), func(p *Point) *Point { return p.SetAdd(p, b) }},
+		"add_self":      {a.Add(a), func(p *Point) *Point { return p.SetAdd(p, p) }},
+		"sub":           {a.Sub(b), func(p *Point) *Point { return p.SetSub(p, b) }},
+		"double":        {a.Double(), func(p *Point) *Point { return p.SetDouble(p) }},
+		"negate":        {a.Negate(), func(p *Point) *Point { return p.SetNegate(p) }},
+		"scalar_mult":   {a.ScalarMult(n), func(p *Point) *Point { return p.SetScalarMult(p, n) }},
+		"scalar_mult_8": {a.ScalarMultBits(n, 8), func(p *Point) *Point { return p.SetScalarMultBits(p, n, 8) }},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			// the receiver aliases the first argument
+			p := &Point{}
+			p.Set(a)
+			if r := tc.set(p); r != p {
+				t.Error("receiver not returned")
+			}
+			if !equalWork(p, tc.expected) {
+				t.Errorf(errmsg, tc.expected, p)
+			}
+
+			allocs := testing.AllocsPerRun(10, func() {
+				p.Set(a)
+				tc.set(p)
+			})
+			if allocs != 0 {
+				t.Errorf("expected no allocations, got %v", allocs)
+			}
+		})
+	}
+
+	p := &Point{}
+	if allocs := testing.AllocsPerRun(10, func() { p.SetScalarMultBaseEd25519(n) }); allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+	if !equalWork(p, ScalarMultBaseEd25519(n)) {
+		t.Error("SetScalarMultBaseEd25519 does not match ScalarMultBaseEd25519")
+	}
+	if !equalWork(p.SetScalarMultBaseLegacy(n), ScalarMultBaseLegacy(n)) {
+		t.Error("SetScalarMultBaseLegacy does not match ScalarMultBaseLegacy")
+	}
+}
+
+func TestStorePackedBatch(t *testing.T) {
+	var points []*Point
+	for _, n := range testScalars() {
+		points = append(points, PointBaseLegacy().ScalarMult(n))
+	}
+	points = append(points, PointBaseLegacy().ScalarMult(&gfOrder), PointBaseEd25519().Negate())
+
+	ed25519 := StorePackedEd25519Batch(points)
+	legacy := StorePackedLegacyBatch(points)
+	if len(ed25519) != len(points) || len(legacy) != len(points) {
+		t.Fatalf("expected %d results, got %d and %d", len(points), len(ed25519), len(legacy))
+	}
+
+	for i, p := range points {
+		if expected, actual := p.StorePackedEd25519(), ed25519[i]; *expected != *actual {
+			t.Errorf(errmsg, expected, actual)
+		}
+		if expected, actual := p.StorePackedLegacy(), legacy[i]; *expected != *actual {
+			t.Errorf(errmsg, expected, actual)
+		}
+	}
+
+	if out := StorePackedEd25519Batch(nil); len(out) != 0 {
+		t.Errorf("expected no results, got %v", out)
+	}
+}
+
+func BenchmarkStorePacked(b *testing.B) {
+	points := make([]*Point, 256)
+	for i, n := range testScalars() {
+		points[i] = PointBaseLegacy().ScalarMult(n)
+	}
+	for i := len(testScalars()); i < len(points); i++ {
+		points[i] = points[i-1].Double()
+	}
+
+	b.Run("single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, p := range points {
+				p.StorePackedEd25519()
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			StorePackedEd25519Batch(points)
+		}
+	})
+}
+
+func TestLoadXY(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		t.Run(fmt.Sprintf("key%d", i), func(t *testing.T) {
+			k := loadInt256File(fmt.Sprintf("testdata/cases/ecc_key_%d", i))
+			expected := loadInt256File(fmt.Sprintf("testdata/cases/ecc_key_derived_public_%d", i))
+			work := PointBaseLegacy().ScalarMult(k)
+
+			for _, curve := range []struct {
+				name  string
+				store func(*Point) (x, y *Int256)
+				load  func(x, y *Int256) (*Point, bool)
+			}{
+				{"legacy", (*Point).StoreXYLegacy, LoadXYLegacy},
+				{"ed25519", (*Point).StoreXYEd25519, LoadXYEd25519},
+			} {
+				x := loadInt256File(fmt.Sprintf("testdata/cases/ecc_key_xy_%s_x_%d", curve.name, i))
+				y := loadInt256File(fmt.Sprintf("testdata/cases/ecc_key_xy_%s_y_%d", curve.name, i))
+
+				if ax, ay := curve.store(work); *ax != *x || *ay != *y {
+					t.Errorf("%s: stored coordinates don't match", curve.name)
+				}
+
+				p, ok := curve.load(x, y)
+				if !ok {
+					t.Fatalf("%s: failed to load coordinates", curve.name)
+				}
+				if actual := p.StorePackedLegacy(); *actual != *expected {
+					t.Errorf(errmsg, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadXYRoundTrip(t *testing.T) {
+	for i, n := range testScalars() {
+		w := PointBaseEd25519().ScalarMult(n)
+
+		x, y := w.StoreXYEd25519()
+		p, ok := LoadXYEd25519(x, y)
+		if !ok || *p.StorePackedEd25519() != *w.StorePackedEd25519() {
+			t.Errorf("ed25519 round trip %d failed", i)
+		}
+
+		x, y = w.StoreXYLegacy()
+		p, ok = LoadXYLegacy(x, y)
+		if !ok || *p.StorePackedLegacy() != *w.StorePackedLegacy() {
+			t.Errorf("legacy round trip %d failed", i)
+		}
+	}
+}
+
+func TestLoadXYInvalid(t *testing.T) {
+	x, y := PointBaseEd25519().StoreXYEd25519()
+
+	tt := map[string]struct {
+		x, y     *Int256
+		expected error
+	}{
+		"x_p":          {loadInt256Hex("edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), y, ErrNonCanonical},
+		"y_2^255-1":    {x, loadInt256Hex("ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), ErrNonCanonical},
+		"y_sign_bit":   {x, loadInt256Hex("0100000000000000000000000000000000000000000000000000000000000080"), ErrNonCanonical},
+		"not_on_curve": {&Int256{1}, &Int256{1}, ErrNotOnCurve},
+		"swapped":      {y, x, ErrNotOnCurve},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			if _, ok := LoadXYEd25519(tc.x, tc.y); ok {
+				t.Error("LoadXYEd25519 accepted invalid coordinates")
+			}
+			if _, ok := LoadXYLegacy(tc.x, tc.y); ok {
+				t.Error("LoadXYLegacy accepted invalid coordinates")
+			}
+			if _, err := DecodeXYEd25519(tc.x, tc.y); err != tc.expected {
+				t.Errorf("DecodeXYEd25519: expected %v, got %v", tc.expected, err)
+			}
+			if _, err := DecodeXYLegacy(tc.x, tc.y); err != tc.expected {
+				t.Errorf("DecodeXYLegacy: expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	if _, err := DecodeXYEd25519(&Int256{}, &Int256{1}); err != ErrSmallOrder {
+		t.Errorf("expected %v, got %v", ErrSmallOrder, err)
+	}
+}
+
+func TestSmallOrderPoints(t *testing.T) {
+	orders := []int{1, 2, 4, 4, 8, 8, 8, 8}
+
+	points := SmallOrderPoints()
+	if len(points) != len(orders) {
+		t.Fatalf("expected %d points, got %d", len(orders), len(points))
+	}
+
+	for i, p := range points {
+		if p == nil {
+			t.Fatalf("point %d is not on the curve", i)
+		}
+		// eight distinct points of order dividing 8 are the whole torsion
+		// subgroup
+		for j := 0; j < i; j++ {
+			if p.Equal(points[j]) {
+				t.Errorf("point %d is equal to point %d", i, j)
+			}
+		}
+
+		// the order is the smallest power of two n with n*p = 0
+		order := 1
+		for q := p; !q.IsIdentity(); q = q.Double() {
+			order *= 2
+		}
+		if order != orders[i] {
+			t.Errorf("point %d: expected order %d, got %d", i, orders[i], order)
+		}
+
+		if !p.IsSmallOrder() {
+			t.Errorf("point %d is not of small order", i)
+		}
+		if !p.MultByCofactor().IsIdentity() {
+			t.Errorf("point %d: 8*p is not the identity", i)
+		}
+		if p.IsTorsionFree() != (i == 0) {
+			t.Errorf("point %d: unexpected IsTorsionFree() = %v", i, p.IsTorsionFree())
+		}
+	}
+
+	// the returned points are copies
+	points[0].SetDouble(points[1])
+	if !SmallOrderPoints()[0].IsIdentity() {
+		t.Error("SmallOrderPoints returned a shared point")
+	}
+}
+
+func TestTorsionFree(t *testing.T) {
+	torsion := SmallOrderPoints()[4]
+
+	for i, n := range testScalars() {
+		w := PointBaseEd25519().ScalarMult(n)
+		if w.IsIdentity() {
+			continue
+		}
+
+		if w.IsSmallOrder() {
+			t.Errorf("point %d: multiple of the base point is of small order", i)
+		}
+		if !w.IsTorsionFree() {
+			t.Errorf("point %d: multiple of the base point is not torsion-free", i)
+		}
+
+		// points of mixed order are neither of small order nor
+		// torsion-free, but 8*w only depends on the prime order component
+		mixed := w.Add(torsion)
+		if mixed.IsSmallOrder() {
+			t.Errorf("point %d: mixed-order point is of small order", i)
+		}
+		if mixed.IsTorsionFree() {
+			t.Errorf("point %d: mixed-order point is torsion-free", i)
+		}
+		if *mixed.MultByCofactor().StorePackedEd25519() != *w.MultByCofactor().StorePackedEd25519() {
+			t.Errorf("point %d: 8*(w+t) != 8*w", i)
+		}
+	}
+}
+
+func TestPointEqual(t *testing.T) {
+	// scales all coordinates of a point, which doesn't change its value
+	scale := func(w *Point, n uint32) *Point {
+		return &Point{
+			X: w.X.multInt(n),
+			Y: w.Y.multInt(n),
+			Z: w.Z.multInt(n),
+			T: w.T.multInt(n),
+		}
+	}
+
+	type testCase struct {
+		expected *Point
+		actual   *Point
+	}
+
+	tt := map[string]testCase{
+		"ecc_point_double": {loadPoint("testdata/cases/ecc_point_double"), PointBaseLegacy().Add(PointBaseLegacy())},
+		"ecc_point_add":    {loadPoint("testdata/cases/ecc_point_add"), scale(PointBaseLegacy(), 12345)},
+	}
+	for i := 0; i < 4; i++ {
+		k := loadInt256File(fmt.Sprintf("testdata/cases/ecc_key_%d", i))
+		tt[fmt.Sprintf("ecc_key_unpacked_%d", i)] = testCase{
+			loadPoint(fmt.Sprintf("testdata/cases/ecc_key_unpacked_%d", i)),
+			scale(k.LoadPackedLegacy().Double().Sub(k.LoadPackedLegacy()), 3),
+		}
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			if !tc.expected.Equal(tc.actual) || !tc.actual.Equal(tc.expected) {
+				t.Errorf(errmsg, tc.expected, tc.actual)
+			}
+			if !tc.expected.Equal(tc.expected) {
+				t.Error("point is not equal to itself")
+			}
+			if tc.expected.Equal(tc.expected.Negate()) {
+				t.Error("point is equal to its negation")
+			}
+			if tc.expected.Equal(tc.expected.Double()) {
+				t.Error("point is equal to its double")
+			}
+			if tc.expected.Equal(&pointIdentity) {
+				t.Error("point is equal to the identity")
+			}
+		})
+	}
+
+	if !pointIdentity.Equal(scale(PointBaseEd25519().Sub(PointBaseEd25519()), 7)) {
+		t.Error("identity elements are not equal")
+	}
+}
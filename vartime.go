@@ -0,0 +1,127 @@
+package libuecc
+
+import "sync"
+
+// Odd multiples B, 3B, ..., 127B of the Ed25519 generator point B in
+// affine cached form, computed on first use
+var (
+	oddMultiplesBaseOnce sync.Once
+	oddMultiplesBase     [64]affineCached
+)
+
+// Computes the odd multiples p, 3p, ..., (2*len(out)-1)p of a point in
+// cached form
+func oddMultiples(p *Point, out []CachedPoint) {
+	var p2 CachedPoint
+	p2.SetPoint(p.Double())
+
+	cur := *p
+	out[0].SetPoint(p)
+	for j := 1; j < len(out); j++ {
+		cur.SetAddCached(&cur, &p2)
+		out[j].SetPoint(&cur)
+	}
+}
+
+// Computes a sliding window non-adjacent form of n
+//
+// Every digit of the result is either zero or odd with an absolute value
+// of at most max, which must be of the form 2^k-1. The time needed
+// depends on n.
+func nonAdjacentForm(n *Int256, max int) (naf [257]int8) {
+	r := [257]int{}
+	for i := 0; i < 256; i++ {
+		r[i] = int(n[i/8]>>(uint(i)&7)) & 1
+	}
+
+	for i := range r {
+		if r[i] == 0 {
+			continue
+		}
+		for b := 1; i+b < len(r) && 1<<uint(b) <= 2*max; b++ {
+			if r[i+b] == 0 {
+				continue
+			}
+			if r[i]+r[i+b]<<uint(b) <= max {
+				r[i] += r[i+b] << uint(b)
+				r[i+b] = 0
+			} else if r[i]-r[i+b]<<uint(b) >= -max {
+				r[i] -= r[i+b] << uint(b)
+				for k := i + b; k < len(r); k++ {
+					if r[k] == 0 {
+						r[k] = 1
+						break
+					}
+					r[k] = 0
+				}
+			} else {
+				break
+			}
+		}
+	}
+
+	for i := range r {
+		naf[i] = int8(r[i])
+	}
+	return
+}
+
+// Adds d*p to cur, given the odd multiples p, 3p, ... in table
+func addOddMultiple(cur *Point, table []CachedPoint, d int8) {
+	switch {
+	case d > 0:
+		cur.SetAddCached(cur, &table[d/2])
+	case d < 0:
+		cur.SetSubCached(cur, &table[-d/2])
+	}
+}
+
+// Like addOddMultiple, but for a table of affine cached points
+func addOddMultipleAffine(cur *Point, table []affineCached, d int8) {
+	switch {
+	case d > 0:
+		cur.setAddAffine(cur, &table[d/2])
+	case d < 0:
+		n := table[-d/2].negate()
+		cur.setAddAffine(cur, &n)
+	}
+}
+
+// DoubleScalarMultVartime computes a*A + b*B, where B is the Ed25519
+// generator point
+//
+// This is much faster than two separate scalar multiplications and is
+// meant for the verification of signatures. The time needed depends on
+// the values of a and b (and A), so it MUST NOT be used with secret
+// inputs.
+func DoubleScalarMultVartime(a *Int256, A *Point, b *Int256) *Point {
+	oddMultiplesBaseOnce.Do(func() {
+		cur := pointBaseEd25519
+		p2 := pointBaseEd25519.Double()
+		for j := range oddMultiplesBase {
+			if j > 0 {
+				cur.SetAdd(&cur, p2)
+			}
+			oddMultiplesBase[j] = cur.affineCached()
+		}
+	})
+
+	var tableA [8]CachedPoint
+	oddMultiples(A, tableA[:])
+
+	nafA := nonAdjacentForm(a, 15)
+	nafB := nonAdjacentForm(b, 127)
+
+	i := len(nafA) - 1
+	for i >= 0 && nafA[i] == 0 && nafB[i] == 0 {
+		i--
+	}
+
+	cur := pointIdentity
+	for ; i >= 0; i-- {
+		cur.SetDouble(&cur)
+		addOddMultiple(&cur, tableA[:], nafA[i])
+		addOddMultipleAffine(&cur, oddMultiplesBase[:], nafB[i])
+	}
+	return &cur
+}
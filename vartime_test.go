@@ -0,0 +1,64 @@
+package libuecc
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestNonAdjacentForm(t *testing.T) {
+	for i, n := range testScalars() {
+		for _, max := range []int{15, 127} {
+			naf := nonAdjacentForm(n, max)
+
+			// undo the recoding, starting with the most significant digit
+			var sum [33]int32
+			for j := len(naf) - 1; j >= 0; j-- {
+				if d := int(naf[j]); d != 0 && (d&1 == 0 || d > max || d < -max) {
+					t.Fatalf("scalar %d: invalid digit %d at %d", i, d, j)
+				}
+				sum[j/8] += int32(naf[j]) << (uint(j) & 7)
+			}
+			var carry int32
+			var actual Int256
+			for j := 0; j < 32; j++ {
+				carry += sum[j]
+				actual[j] = uint8(carry)
+				carry >>= 8
+			}
+			carry += sum[32]
+
+			if carry != 0 || actual != *n {
+				t.Errorf("scalar %d: NAF does not match: %x", i, n[:])
+			}
+		}
+	}
+}
+
+func TestDoubleScalarMultVartime(t *testing.T) {
+	A := PointBaseLegacy().ScalarMult(loadInt256File("testdata/cases/ecc_key_0"))
+	scalars := testScalars()
+
+	for i, a := range scalars {
+		b := scalars[(i+7)%len(scalars)]
+
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			expected := A.ScalarMult(a).Add(ScalarMultBaseEd25519(b)).StorePackedEd25519()
+			actual := DoubleScalarMultVartime(a, A, b).StorePackedEd25519()
+
+			if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+				t.Errorf(errmsg, expected, actual)
+			}
+		})
+	}
+}
+
+func BenchmarkDoubleScalarMultVartime(b *testing.B) {
+	A := PointBaseLegacy().ScalarMult(loadInt256File("testdata/cases/ecc_key_0"))
+	n1 := loadInt256File("testdata/cases/ecc_key_1")
+	n2 := loadInt256File("testdata/cases/ecc_key_2")
+
+	for i := 0; i < b.N; i++ {
+		DoubleScalarMultVartime(n1, A, n2)
+	}
+}
@@ -0,0 +1,143 @@
+package libuecc
+
+import "crypto/subtle"
+
+// X25519 computes the Diffie-Hellman function of RFC 7748 on
+// Curve25519, the Montgomery form of the Ed25519 curve
+//
+// The scalar is clamped like SanitizeSecret and the most significant bit
+// of the u-coordinate is ignored. Pass the u-coordinate 9 to compute a
+// public key. ErrSmallOrder is returned if the result is all zeros, which
+// happens if u is a point of small order (on the curve or its twist), so
+// the result doesn't depend on the scalar.
+func X25519(scalar, u [32]byte) ([32]byte, error) {
+	k := NewInt256(scalar[:]).SanitizeSecret()
+	out := *x25519Ladder(k, feFromInt256(NewInt256(u[:])))
+
+	var zero [32]byte
+	if subtle.ConstantTimeCompare(out[:], zero[:]) == 1 {
+		return zero, ErrSmallOrder
+	}
+	return out, nil
+}
+
+// (A-2)/4 = 121665, where A = 486662 is the parameter of Curve25519
+const a24 = 121665
+
+// Multiplies the point with the u-coordinate u by the scalar k using the
+// Montgomery ladder (RFC 7748, section 5), k must be sanitized
+func x25519Ladder(k *Int256, u fe) *Int256 {
+	x1 := u
+	x2, z2 := feOne, feZero
+	x3, z3 := u, feOne
+
+	var swap uint32
+	for t := 254; t >= 0; t-- {
+		kt := uint32(k[t>>3]>>uint(t&7)) & 1
+
+		swap ^= kt
+		x2, x3 = selectFe(x2, x3, swap), selectFe(x3, x2, swap)
+		z2, z3 = selectFe(z2, z3, swap), selectFe(z3, z2, swap)
+		swap = kt
+
+		A := x2.add(z2)
+		AA := A.square()
+		B := x2.sub(z2)
+		BB := B.square()
+		E := AA.sub(BB)
+		C := x3.add(z3)
+		D := x3.sub(z3)
+		DA := D.mult(A)
+		CB := C.mult(B)
+
+		x3 = DA.add(CB).square()
+		z3 = x1.mult(DA.sub(CB).square())
+		x2 = AA.mult(BB)
+		z2 = E.mult(AA.add(E.multInt(a24)))
+	}
+
+	x2 = selectFe(x2, x3, swap)
+	z2 = selectFe(z2, z3, swap)
+
+	// z2 = 0 for points of small order, which gives the result 0 as the
+	// reciprocal of zero is zero
+	return x2.mult(z2.recip()).toInt256()
+}
+
+// MontgomeryU returns the u-coordinate of the point on Curve25519
+// corresponding to a point of the Elliptic Curve, as used by X25519
+//
+// Both the Ed25519 and the legacy curve are birationally equivalent to
+// Curve25519 with u = (1 + y) / (1 - y), where the y coordinate is the
+// same for both curves. The identity element is mapped to u = 0 like the
+// point of order 2.
+func (w *Point) MontgomeryU() [32]byte {
+	// u = (Z + Y) / (Z - Y) in projective coordinates
+	n := w.Z.add(w.Y)
+	d := w.Z.sub(w.Y)
+	return *n.mult(d.recip()).toInt256()
+}
+
+// PointFromMontgomeryU returns the point of the Elliptic Curve
+// corresponding to the u-coordinate of a point on Curve25519
+//
+// As every u-coordinate belongs to two points, sign selects the one
+// whose Ed25519 X coordinate has the given parity (0 or 1), i.e. the
+// most significant bit of the packed Ed25519 representation. For a point
+// in the legacy representation, the sign must be taken from its Ed25519
+// encoding: the most significant bit of the packed legacy representation
+// is the parity of Y, and the legacy X coordinate (a multiple of the
+// Ed25519 one) usually has a different parity as well.
+//
+// ErrNonCanonical is returned if u isn't in the range [0,p-1] or if sign
+// is 1 for X = 0, ErrNotOnCurve if u belongs to the twist of Curve25519
+// or is -1, which has no equivalent point on the Edwards curves.
+func PointFromMontgomeryU(u [32]byte, sign uint32) (*Point, error) {
+	in := NewInt256(u[:])
+	if !isCanonicalFe(in) {
+		return nil, ErrNonCanonical
+	}
+
+	// y = (u - 1) / (u + 1)
+	U := feFromInt256(in)
+	d := U.add(feOne).squeeze()
+	if d.isZero() {
+		return nil, ErrNotOnCurve
+	}
+	y := U.sub(feOne).mult(d.recip()).toInt256()
+
+	y[31] |= uint8(sign&1) << 7
+	return loadPackedEd25519Strict(y)
+}
+
+// X25519PrivateKeyFromEd25519 converts the secret seed of an Ed25519 key
+// into an X25519 private key
+//
+// The private key is the clamped first half of the SHA-512 digest of the
+// seed, which is the secret scalar of the Ed25519 key. X25519 with the
+// u-coordinate 9 gives the same public key as
+// X25519PublicKeyFromEd25519.
+func X25519PrivateKeyFromEd25519(seed *Int256) [32]byte {
+	s, _ := expandSeed(seed)
+	return *s
+}
+
+// X25519PublicKeyFromEd25519 converts a packed Ed25519 public key into
+// an X25519 public key
+//
+// The public key is decoded like DecodePackedEd25519: ErrNonCanonical is
+// returned for non-canonical encodings, ErrNotOnCurve if it doesn't
+// encode a point of the curve and ErrSmallOrder for the points of small
+// order, which would make any shared secret predictable. Points with a
+// small order component, i.e. outside of the prime order subgroup, are
+// rejected with ErrSmallOrder as well, like libsodium does.
+func X25519PublicKeyFromEd25519(pub *Int256) ([32]byte, error) {
+	p, err := checkOrder(loadPackedEd25519Strict(pub))
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if !p.IsTorsionFree() {
+		return [32]byte{}, ErrSmallOrder
+	}
+	return p.MontgomeryU(), nil
+}
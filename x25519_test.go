@@ -0,0 +1,249 @@
+package libuecc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toArray(b []byte) (out [32]byte) {
+	copy(out[:], b)
+	return
+}
+
+func TestX25519RFC7748(t *testing.T) {
+	for _, v := range loadVectors(t, "testdata/rfc7748/x25519.txt") {
+		scalar, u, expected := toArray(v[0]), toArray(v[1]), toArray(v[2])
+
+		t.Run(hex.EncodeToString(scalar[:4]), func(t *testing.T) {
+			actual, err := X25519(scalar, u)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestX25519Iterated(t *testing.T) {
+	tt := []struct {
+		iterations int
+		expected   string
+	}{
+		{1, "422c8e7a6227d7bca1350b3e2bb7279f7897b87bb6854b783c60e80311ae3079"},
+		{1000, "684cf59ba83309552800ef566f2f4d3c1c3887c49360e3875f2eb94d99532c51"},
+	}
+
+	for _, tc := range tt {
+		// k = X25519(k, u), u = old k, starting with k = u = 9
+		k, u := [32]byte{9}, [32]byte{9}
+		for i := 0; i < tc.iterations; i++ {
+			r, err := X25519(k, u)
+			require.NoError(t, err)
+			k, u = r, k
+		}
+		assert.Equal(t, tc.expected, hex.EncodeToString(k[:]), "%d iterations", tc.iterations)
+	}
+}
+
+func TestX25519SmallOrder(t *testing.T) {
+	scalar := toArray(loadInt256Hex("a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4")[:])
+
+	// u-coordinates of points of small order on the curve and its twist,
+	// including non-canonical encodings
+	for _, s := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"e0eb7a7c3b41b8ae1656e3faf19fc46ada098deb9c32b1fd866205165f49b800",
+		"5f9c95bca3508c24b1d0b1559c83ef5b04445cc4581c8e86d8224eddd09f1157",
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+	} {
+		out, err := X25519(scalar, toArray(loadInt256Hex(s)[:]))
+		assert.Equal(t, ErrSmallOrder, err, s)
+		assert.Equal(t, [32]byte{}, out, s)
+	}
+}
+
+func BenchmarkX25519(b *testing.B) {
+	scalar := toArray(loadInt256Hex("a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4")[:])
+	u := [32]byte{9}
+
+	for i := 0; i < b.N; i++ {
+		X25519(scalar, u)
+	}
+}
+
+// Returns the points of the packed legacy public keys of the generated
+// data
+func legacyTestPoints(t *testing.T) []*Point {
+	var points []*Point
+	for i := 0; i < 4; i++ {
+		w := loadInt256File(fmt.Sprintf("testdata/cases/ecc_key_derived_public_%d", i)).LoadPackedLegacy()
+		require.NotNil(t, w, i)
+		points = append(points, w)
+	}
+	return points
+}
+
+func TestMontgomeryU(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([32]byte{9}, PointBaseEd25519().MontgomeryU())
+	assert.Equal([32]byte{}, pointIdentity.MontgomeryU())
+
+	// X25519 and the scalar multiplication on the Edwards curve must
+	// agree
+	base := PointBaseEd25519()
+	for i, n := range testScalars() {
+		k := n.SanitizeSecret()
+
+		expected, err := X25519(toArray(k[:]), base.MontgomeryU())
+		require.NoError(t, err)
+		assert.Equal(expected, base.ScalarMult(k).MontgomeryU(), "%d", i)
+	}
+
+	// u = (1 + y) / (1 - y) for points loaded from legacy encodings
+	p := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	one := big.NewInt(1)
+	for i, w := range legacyTestPoints(t) {
+		_, y := w.StoreXYLegacy()
+		yb := toBig(y)
+
+		u := new(big.Int).Sub(one, yb)
+		u.ModInverse(u.Mod(u, p), p)
+		u.Mul(u, new(big.Int).Add(one, yb)).Mod(u, p)
+		mu := w.MontgomeryU()
+		assert.Equal(u, toBig(NewInt256(mu[:])), "%d", i)
+
+		k := testScalars()[5+i].SanitizeSecret()
+		expected, err := X25519(toArray(k[:]), w.MontgomeryU())
+		require.NoError(t, err)
+		assert.Equal(expected, w.ScalarMult(k).MontgomeryU(), "%d", i)
+	}
+}
+
+func TestPointFromMontgomeryU(t *testing.T) {
+	for i, n := range testScalars() {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert := assert.New(t)
+
+			// the identity element is mapped to u = 0 as well, but can't
+			// be recovered from it
+			w := PointBaseEd25519().ScalarMult(n)
+			if w.IsIdentity() {
+				t.Skip("identity")
+			}
+
+			sign := uint32(w.StorePackedEd25519()[31] >> 7)
+
+			p, err := PointFromMontgomeryU(w.MontgomeryU(), sign)
+			require.NoError(t, err)
+			assert.True(p.Equal(w))
+			assert.Equal(w.StorePackedEd25519(), p.StorePackedEd25519())
+
+			// the other sign gives the negation
+			p, err = PointFromMontgomeryU(w.MontgomeryU(), sign^1)
+			require.NoError(t, err)
+			assert.True(p.Equal(w.Negate()))
+		})
+	}
+
+	// Points loaded from legacy encodings round-trip with the sign of
+	// their Ed25519 encoding, but not generally with the most
+	// significant bit of the legacy encoding (the parity of Y)
+	mismatches := 0
+	for i, w := range legacyTestPoints(t) {
+		expected := w.StorePackedLegacy()
+		sign := uint32(w.StorePackedEd25519()[31] >> 7)
+
+		p, err := PointFromMontgomeryU(w.MontgomeryU(), sign)
+		require.NoError(t, err, i)
+		assert.Equal(t, expected, p.StorePackedLegacy(), "%d", i)
+
+		p, err = PointFromMontgomeryU(w.MontgomeryU(), sign^1)
+		require.NoError(t, err, i)
+		assert.NotEqual(t, expected, p.StorePackedLegacy(), "%d", i)
+
+		legacySign := uint32(expected[31] >> 7)
+		p, err = PointFromMontgomeryU(w.MontgomeryU(), legacySign)
+		require.NoError(t, err, i)
+		assert.Equal(t, legacySign == sign, p.Equal(w), "%d", i)
+		if legacySign != sign {
+			mismatches++
+		}
+	}
+	assert.NotZero(t, mismatches, "no vector with different sign bits")
+}
+
+func TestPointFromMontgomeryUInvalid(t *testing.T) {
+	tt := map[string]struct {
+		u        string
+		sign     uint32
+		expected error
+	}{
+		"p":             {"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f", 0, ErrNonCanonical},
+		"high_bit":      {"0900000000000000000000000000000000000000000000000000000000000080", 0, ErrNonCanonical},
+		"negative_zero": {"0000000000000000000000000000000000000000000000000000000000000000", 1, ErrNonCanonical},
+		"minus_one":     {"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f", 0, ErrNotOnCurve},
+		"twist":         {"0200000000000000000000000000000000000000000000000000000000000000", 0, ErrNotOnCurve},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			_, err := PointFromMontgomeryU(toArray(loadInt256Hex(tc.u)[:]), tc.sign)
+			assert.Equal(t, tc.expected, err)
+		})
+	}
+
+	// u = 0 is the point of order 2
+	p, err := PointFromMontgomeryU([32]byte{}, 0)
+	require.NoError(t, err)
+	assert.True(t, p.Equal(SmallOrderPoints()[1]))
+}
+
+func TestX25519FromEd25519(t *testing.T) {
+	for _, v := range loadVectors(t, "testdata/libsodium/ed25519_convert.txt") {
+		seed, pub := NewInt256(v[0]), NewInt256(v[1])
+		priv, xpub := toArray(v[2]), toArray(v[3])
+
+		t.Run(hex.EncodeToString(pub[:4]), func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(pub, PublicKeyEd25519(seed))
+			assert.Equal(priv, X25519PrivateKeyFromEd25519(seed))
+
+			actual, err := X25519PublicKeyFromEd25519(pub)
+			require.NoError(t, err)
+			assert.Equal(xpub, actual)
+
+			actual, err = X25519(priv, [32]byte{9})
+			require.NoError(t, err)
+			assert.Equal(xpub, actual)
+		})
+	}
+}
+
+func TestX25519PublicKeyFromEd25519Invalid(t *testing.T) {
+	for i, p := range SmallOrderPoints() {
+		_, err := X25519PublicKeyFromEd25519(p.StorePackedEd25519())
+		assert.Equal(t, ErrSmallOrder, err, i)
+	}
+
+	_, err := X25519PublicKeyFromEd25519(&Int256{2})
+	assert.Equal(t, ErrNotOnCurve, err)
+
+	// p+1 is a non-canonical encoding of y = 1
+	_, err = X25519PublicKeyFromEd25519(loadInt256Hex("eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"))
+	assert.Equal(t, ErrNonCanonical, err)
+
+	// the base point plus a point of order 8 is on the curve, but not in
+	// the prime order subgroup
+	mixed := PointBaseEd25519().Add(SmallOrderPoints()[4])
+	_, err = X25519PublicKeyFromEd25519(mixed.StorePackedEd25519())
+	assert.Equal(t, ErrSmallOrder, err)
+}